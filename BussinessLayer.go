@@ -1,11 +1,22 @@
 package main
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -44,6 +55,21 @@ func initializeChaincode(stub shim.ChaincodeStubInterface, args []string) error
 	if !ok {
 		return err
 	}
+
+	//Salt for document hashes in the financing registry, derived from the transaction so every peer agrees
+	registrySalt := sha256.Sum256([]byte(stub.GetTxID() + "financingRegistry"))
+	ok = updateRegistrySalt(stub, hex.EncodeToString(registrySalt[:]))
+	if !ok {
+		return errors.New("Error in creating financing registry")
+	}
+
+	//Register the initial admin
+	if len(args) == 1 {
+		ok = updateUserRoles(stub, args[0], []string{Role_Admin})
+		if !ok {
+			return errors.New("Error in registering admin user")
+		}
+	}
 	return nil
 }
 
@@ -65,11 +91,21 @@ func saveContractDetails(stub shim.ChaincodeStubInterface, args []string) ([]byt
 	var err error
 	var ok bool
 
-	if len(args) != 1 {
-		return nil, errors.New("Incorrect number of arguments. Need 1 arguments")
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
 	}
 
-	json.Unmarshal([]byte(args[0]), &contractDetails)
+	err = json.Unmarshal([]byte(args[0]), &contractDetails)
+	if err != nil {
+		return nil, errors.New("Invalid contract details")
+	}
+
+	//The client picks the pricing salt, a salt the chaincode derived could be recomputed by any ledger reader
+	pricingSalt := args[1]
+	ok, err = checkPricingSalt(pricingSalt)
+	if !ok {
+		return nil, err
+	}
 
 	/* Commented becouse v0.6 does not support it
 		//Datatype checking
@@ -84,43 +120,136 @@ func saveContractDetails(stub shim.ChaincodeStubInterface, args []string) ([]byt
 			return nil, err
 		}
 
-		//Delivary Date Duration checking
-		DeliveryDate, _ := time.Parse(time.RFC3339, contractDetails.DeliveryDetails.DeliveryDate)
-		CurrentDate := time.Now()
-		Days := DiffDays(int(DeliveryDate.Year()), int(DeliveryDate.Month()), int(DeliveryDate.Day()), int(CurrentDate.Year()), int(CurrentDate.Month()), int(CurrentDate.Day()))
+	  comment ending */
+
+	//Trade amount checking
+	ok, err = checkTradeAmounts(contractDetails)
+	if !ok {
+		return nil, err
+	}
+
+	contractDetails = addContractInformation(contractDetails)
+
+	//Pin the configuration in effect on the create date
+	config := getEffectiveConfiguration(stub, contractDetails.ContractCreateDate)
+	contractDetails.ConfigVersion = config.Version
+
+	//Payment, transport and delivery durations within its limits
+	ok, err = checkContractDurations(contractDetails, config)
+	if !ok {
+		return nil, err
+	}
 
-		if Days < Min_Days_DeliveryDuration {
-			return nil, errors.New("Delivery Duration must be greater than " + string(Min_Days_DeliveryDuration) + " days")
-		} else if Days > Max_Days_DeliveryDuration {
-			return nil, errors.New("Payment Duration must be less than " + string(Max_Days_DeliveryDuration) + " days")
+	//Pin the banks' fee schedules, later changes do not reach agreed contracts
+	sellerBankFees, _ := getFeeSchedule(stub, contractDetails.SellerDetails.SellerBank.UserId)
+	contractDetails.SellerBankFeeVersion = sellerBankFees.Version
+	buyerBankFees, _ := getFeeSchedule(stub, contractDetails.BuyerDetails.BuyerBank.UserId)
+	contractDetails.BuyerBankFeeVersion = buyerBankFees.Version
+
+	//Penalty rules agreed on the contract, else the seller organisation's current rules
+	if len(contractDetails.PenaltyRules) != 0 {
+		ok, err = validatePenaltyRules(contractDetails.PenaltyRules)
+		if !ok {
+			return nil, err
 		}
-	  //duration := time.Since(deliveryDate)
-		//if int(duration.Hours()) < (Min_Days_DeliveryDuration * 24) {
-			//return nil, errors.New("Delivery Duration must be greater than " + string(Min_Days_DeliveryDuration) + " days")
-		//} else if int(duration.Hours()) > (Max_Days_DeliveryDuration * 24) {
-			//return nil, errors.New("Payment Duration must be less than " + string(Max_Days_DeliveryDuration) + " days")
-		//}
+		contractDetails.PenaltyRuleSource = "contract"
+	} else if ruleSetList := getPenaltyRuleSetList(stub, contractDetails.SellerDetails.Seller.UserId); len(ruleSetList) != 0 {
+		ruleSet := ruleSetList[len(ruleSetList)-1]
+		contractDetails.PenaltyRules = ruleSet.Rules
+		contractDetails.PenaltyRuleSource = "organisation " + ruleSet.OrganisationId + " version " + strconv.Itoa(ruleSet.Version)
+	} else {
+		contractDetails.PenaltyRuleSource = "configuration version " + strconv.Itoa(contractDetails.ConfigVersion)
+	}
 
-		// Payment duartion checking
-		PaymentDuration, _ := strconv.Atoi(contractDetails.TradeConditions.PaymentDuration)
-		if PaymentDuration < Min_Days_PaymentDuration {
-			return nil, errors.New("Payment Duration must be gereater than " + string(Min_Days_PaymentDuration) + " days")
-		} else if PaymentDuration > Max_Days_PaymentDuration {
-			return nil, errors.New("Payment Duration must be less than " + string(Max_Days_PaymentDuration) + " days")
+	//Incoterms 2020 decide where risk and cost pass to the buyer
+	rule, found := Incoterms[strings.ToUpper(strings.TrimSpace(contractDetails.DeliveryDetails.Incoterm))]
+	if !found {
+		return nil, errors.New("Incoterm " + contractDetails.DeliveryDetails.Incoterm + " is not an Incoterms 2020 rule")
+	}
+	contractDetails.DeliveryDetails.Incoterm = rule.Code
+	contractDetails.RiskTransfer = &riskTransfer{incotermRule: rule}
+
+	//Transport legs, the first leg's transporter collects the goods
+	legs := contractDetails.DeliveryDetails.TransportLegs
+	if len(legs) != 0 {
+		if contractDetails.DeliveryDetails.TransporterDetails.UserId == "" {
+			contractDetails.DeliveryDetails.TransporterDetails = legs[0].Transporter
+		}
+		ok, err = validateTransportLegs(contractDetails, rule)
+		if !ok {
+			return nil, err
+		}
+		for i := range legs {
+			legs[i].LegNumber = i + 1
+			legs[i].Status = Leg_Status_Pending
+			legs[i].StartedDate = ""
+			legs[i].CompletedDate = ""
+			legs[i].HandedOverTo = ""
 		}
+	}
 
-		// Transport duartion checking
-		TransportDuration, _ := strconv.Atoi(contractDetails.TradeConditions.TransportDuration)
-		if TransportDuration < Min_Days_TransportDuration {
-			return nil, errors.New("Payment Duration must be gereater than " + string(Min_Days_TransportDuration) + " days")
-		} else if TransportDuration > Max_Days_TransportDuration {
-			return nil, errors.New("Payment Duration must be less than " + string(Max_Days_TransportDuration) + " days")
+	//Late payment interest terms
+	if contractDetails.LateInterest != nil {
+		if contractDetails.LateInterest.DayCount == "" {
+			contractDetails.LateInterest.DayCount = DayCount_Act360
 		}
-	  comment ending */
+		ok, err = validateLateInterestTerms(*contractDetails.LateInterest)
+		if !ok {
+			return nil, err
+		}
+	}
 
-	contractDetails = addContractInformation(contractDetails)
+	//Cargo condition thresholds
+	ok, err = validateConditionRules(contractDetails.ConditionRules)
+	if !ok {
+		return nil, err
+	}
+	contractDetails.ConditionBreached = false
+	contractDetails.FirstBreachAt = ""
+
+	//Bank charges, by default each side pays its own bank
+	if contractDetails.ChargesClause == "" {
+		contractDetails.ChargesClause = Charges_Sha
+	}
+	if contractDetails.ChargesClause != Charges_Our && contractDetails.ChargesClause != Charges_Ben && contractDetails.ChargesClause != Charges_Sha {
+		return nil, errors.New("Charges clause must be " + Charges_Our + ", " + Charges_Ben + " or " + Charges_Sha)
+	}
+
+	//Tax and import duty by destination country
+	contractDetails.Taxes, err = lookupContractTaxes(stub, contractDetails)
+	if err != nil {
+		return nil, err
+	}
+	contractDetails, err = applyContractTaxes(contractDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	//Payment schedule, by default the whole amount on invoice
+	if len(contractDetails.PaymentSchedule) == 0 {
+		paymentDuration, _ := strconv.Atoi(contractDetails.TradeConditions.PaymentDuration)
+		contractDetails.PaymentSchedule = []milestone{{
+			MilestoneId:   "balance",
+			Description:   "Balance on invoice",
+			TriggerStatus: Invoice_Created,
+			Percentage:    100,
+			DueDays:       paymentDuration,
+		}}
+	}
+	ok, err = validatePaymentSchedule(contractDetails.PaymentSchedule)
+	if !ok {
+		return nil, err
+	}
+
+	//Sanctions screening
+	screening := screenContract(stub, contractDetails, contractDetails.ContractStatus)
+	contractDetails = applyScreeningResult(contractDetails, screening)
+	ok = recordScreeningResult(stub, screening)
+	if !ok {
+		return nil, errors.New("Error in recording screening result")
+	}
 
-	ok, err = insertContractDetails(stub, contractDetails)
+	ok, err = insertContractDetails(stub, contractDetails, pricingSalt)
 	if !ok && err == nil {
 		return nil, errors.New("Error in adding OrderDetails record")
 	}
@@ -130,13 +259,31 @@ func saveContractDetails(stub shim.ChaincodeStubInterface, args []string) ([]byt
 		return nil, errors.New("Error in adding OrderDetails record")
 	}
 
+	ok = triggerMilestones(stub, contractDetails, getMilestoneStatusList(stub, contractDetails.ContractId))
+	if !ok {
+		return nil, errors.New("Error in updating payment schedule")
+	}
+
 	return nil, nil
 }
 
 func getContractDetailsByContractId(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 
+	if len(args) < 1 || len(args) > 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 to 3 arguments")
+	}
+
+	//Pricing is redacted in the response unless a trading party signs the pricing access hash
 	contractId := args[0]
-	contractDetails, _ := getContractDetails(stub, contractId)
+	userId := ""
+	signature := ""
+	if len(args) > 1 {
+		userId = args[1]
+	}
+	if len(args) > 2 {
+		signature = args[2]
+	}
+	contractDetails := getContractDetailsForUser(stub, contractId, userId, signature)
 
 	jsonAsBytes, _ := json.Marshal(contractDetails)
 	return jsonAsBytes, nil
@@ -193,17 +340,75 @@ func addContractInformation(contractDetails contract) contract {
 	contractDetails.ContractStatus = "Contract Created"
 
 	//calculate TotalTradeAmount
-	var TotalTradeAmount float64
-	TotalTradeAmount = 0
-	for _, element := range contractDetails.TradeDetails {
-		Amount, _ := strconv.ParseFloat(element.TotalAmount, 64)
-		TotalTradeAmount = TotalTradeAmount + Amount
-	}
-	contractDetails.TotalTradeAmount = TotalTradeAmount
+	contractDetails.TotalTradeAmount, _ = calculateTradeAmount(contractDetails)
 
 	return contractDetails
 }
 
+// calculateTradeAmount checks each line's TotalAmount against price times quantity and returns their sum
+func calculateTradeAmount(contractDetails contract) (money, error) {
+	currency := contractDetails.TradeConditions.Currency
+	total := money{Currency: currency}
+
+	for i, element := range contractDetails.TradeDetails {
+		line := strconv.Itoa(i + 1)
+
+		price, err := parseDecimal(element.ProductPrice)
+		if err != nil || price.Sign() < 0 {
+			return total, errors.New("Invalid ProductPrice on line " + line)
+		}
+		quantity, err := parseDecimal(element.ProductQuantity)
+		if err != nil || quantity.Sign() <= 0 {
+			return total, errors.New("Invalid ProductQuantity on line " + line)
+		}
+		amount, err := parseMoney(element.TotalAmount, currency)
+		if err != nil {
+			return total, errors.New("Invalid TotalAmount on line " + line + ": " + err.Error())
+		}
+
+		expected, err := roundMoney(new(big.Rat).Mul(price, quantity), currency)
+		if err != nil {
+			return total, errors.New("Invalid TotalAmount on line " + line + ": " + err.Error())
+		}
+		if amount != expected {
+			return total, errors.New("TotalAmount on line " + line + " must be " + expected.String() + " " + currency)
+		}
+
+		total, err = total.Add(amount)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func checkTradeAmounts(contractDetails contract) (bool, error) {
+	currency := contractDetails.TradeConditions.Currency
+	if !isSupportedCurrency(currency) {
+		return false, errors.New("Unsupported currency " + currency)
+	}
+	if len(contractDetails.TradeDetails) == 0 {
+		return false, errors.New("At least one product line is required")
+	}
+
+	total, err := calculateTradeAmount(contractDetails)
+	if err != nil {
+		return false, err
+	}
+
+	//A total sent by the client must match the sum of the lines, plain numbers are in the contract currency
+	supplied, err := contractDetails.TotalTradeAmount.inCurrency(currency)
+	if err != nil {
+		return false, err
+	}
+	if !supplied.IsZero() && supplied != total {
+		return false, errors.New("TotalTradeAmount must be " + total.String() + " " + currency)
+	}
+
+	return true, nil
+}
+
 func updateUsersContractList(stub shim.ChaincodeStubInterface, contractDetails contract) (bool, error) {
 	var ok bool
 	var userContractList []string
@@ -263,6 +468,22 @@ func updateUsersContractList(stub shim.ChaincodeStubInterface, contractDetails c
 		return ok, errors.New("Error in updating Transporter's contract list")
 	}
 
+	//Update each further leg transporter's Contract List
+	for _, transporterId := range legTransporters(contractDetails) {
+		if transporterId == contractDetails.DeliveryDetails.TransporterDetails.UserId {
+			continue
+		}
+		userContractList, ok = getUserContractList(stub, transporterId)
+		if !ok {
+			return ok, errors.New("Error in geting leg Transporter's contract list")
+		}
+		userContractList = append(userContractList, contractDetails.ContractId)
+		ok = updateUserContractList(stub, transporterId, userContractList)
+		if !ok {
+			return ok, errors.New("Error in updating leg Transporter's contract list")
+		}
+	}
+
 	return true, nil
 }
 
@@ -285,7 +506,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 		for _, element := range contractIdList {
 			contractId := element
-			contract, _ = getContractDetails(stub, contractId)
+			contract = getContractDetailsForUser(stub, contractId, userId, "")
 			contractDetails = append(contractDetails, contract)
 		}
 
@@ -312,7 +533,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 				for _, element := range contractIdList {
 					contractId := element
-					contract, _ = getContractDetails(stub, contractId)
+					contract = getContractDetailsForUser(stub, contractId, userId, "")
 					if contract.ContractStatus == Contract_Created || contract.ContractStatus == Contract_Accepted {
 						contractDetails = append(contractDetails, contract)
 					}
@@ -327,7 +548,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 				for _, element := range contractIdList {
 					contractId := element
-					contract, _ = getContractDetails(stub, contractId)
+					contract = getContractDetailsForUser(stub, contractId, userId, "")
 					if contract.ContractStatus == LC_Created || contract.ContractStatus == LC_Approved {
 						contractDetails = append(contractDetails, contract)
 					}
@@ -342,7 +563,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 				for _, element := range contractIdList {
 					contractId := element
-					contract, _ = getContractDetails(stub, contractId)
+					contract = getContractDetailsForUser(stub, contractId, userId, "")
 					if contract.ContractStatus == Ready_For_Shipment || contract.ContractStatus == Shipment_Inprogress || contract.ContractStatus == Shipment_Delivered {
 						contractDetails = append(contractDetails, contract)
 					}
@@ -357,7 +578,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 				for _, element := range contractIdList {
 					contractId := element
-					contract, _ = getContractDetails(stub, contractId)
+					contract = getContractDetailsForUser(stub, contractId, userId, "")
 					if contract.ContractStatus == Invoice_Created || contract.ContractStatus == Payment_Completed_to_Seller_Bank || contract.ContractStatus == Payment_Completed_to_Seller {
 						contractDetails = append(contractDetails, contract)
 					}
@@ -372,7 +593,7 @@ func getContractDetailsByUserId(stub shim.ChaincodeStubInterface, args []string)
 
 				for _, element := range contractIdList {
 					contractId := element
-					contract, _ = getContractDetails(stub, contractId)
+					contract = getContractDetailsForUser(stub, contractId, userId, "")
 					if contract.ContractStatus == Contract_Completed {
 						contractDetails = append(contractDetails, contract)
 					}
@@ -681,6 +902,7 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 	var sortedDetails Sorted
 
 	var contractDetails []contract
+	var pricedContracts []contract
 	var contractVar contract
 
 	var notificationCount int
@@ -710,11 +932,14 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 	var pendingfrombuyerbank int
 	var completedbuyer int
 
-	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Need 2 argument")
+	if len(args) != 1 && len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 or 4 argument")
+	}
+	if len(args) == 4 && !verifyPricingAccess(stub, args[0], args[3]) {
+		return nil, errors.New("Trade totals need a signed pricing access hash")
 	}
 	userId := args[0]
-	userRole := args[1]
+	staticDetails.NotificationByRole = map[string]int{}
 
 	contractIdList := []string{}
 	//contractDetails := []contract{}
@@ -729,7 +954,8 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 		contractId := element
 		contractVar, _ = getContractDetails(stub, contractId)
 
-		contractDetails = append(contractDetails, contractVar)
+		contractDetails = append(contractDetails, redactContractPricing(contractVar))
+		pricedContracts = append(pricedContracts, contractVar)
 
 		CurrentDate := time.Now().Local()
 
@@ -747,8 +973,9 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 
 		// NotificationCount Check
 
-		if contractVar.ActionPendingOn == userRole {
+		if pendingRole, pending := getPendingRole(contractVar, userId); pending {
 			notificationCount++
+			staticDetails.NotificationByRole[pendingRole]++
 		}
 
 		// Counts Check
@@ -799,6 +1026,17 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 			completedbuyer++
 		}
 
+		//Outstanding milestone amounts
+		if isPricingParty(contractVar, userId) {
+			for _, element := range getMilestoneStatusList(stub, contractId) {
+				if element.Status == Milestone_Due || element.Status == Milestone_PartiallyPaid {
+					outstanding, _ := element.AmountDue.Sub(element.AmountPaid)
+					staticDetails.PaymentStatus.DueMilestones++
+					staticDetails.PaymentStatus.Outstanding = addMoneyByCurrency(staticDetails.PaymentStatus.Outstanding, outstanding)
+				}
+			}
+		}
+
 		// Shipment, Delivery Status Check
 		if contractVar.ContractStatus == Ready_For_Shipment {
 			pending++
@@ -818,6 +1056,15 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 
 	staticDetails.TotalContracts = len(contractIdList)
 
+	//Trade totals in the caller's reporting currency
+	if len(args) == 4 {
+		totals, err := calculateTradeTotals(stub, pricedContracts, userId, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		staticDetails.TradeTotals = &totals
+	}
+
 	if staticDetails.TotalContracts == 0 {
 		//staticDetails.ContractList = latestContracts
 		//staticDataAsBytes, _ := json.Marshal(staticDetails)
@@ -863,13 +1110,14 @@ func getStaticDetailsByUserId(stub shim.ChaincodeStubInterface, args []string) (
 	sortedDetails = contractDetails
 	sort.Sort(sortedDetails)
 
-	if sortedDetails.Len() < 5 {
+	latestContractsCount := getEffectiveConfiguration(stub, time.Now().Local()).LatestContractsCount
+	if sortedDetails.Len() < latestContractsCount {
 		numberofContracts := sortedDetails.Len()
 		for j := 0; j < numberofContracts; j++ {
 			latestContracts = append(latestContracts, sortedDetails[j])
 		}
 	} else {
-		for j := 0; j < nc; j++ {
+		for j := 0; j < latestContractsCount; j++ {
 			latestContracts = append(latestContracts, sortedDetails[j])
 		}
 	}
@@ -887,15 +1135,21 @@ func inTimeSpan(start, end, check time.Time) bool {
 
 func getNotificationStatus(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
 
+	var pendingActions []pendingAction
 	var contractDetails []contract
 	var contract contract
 	var sortedDetails Sorted
 
-	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Need 2 argument")
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 or 2 argument")
 	}
 	userId := args[0]
-	userRole := args[1]
+
+	//Optional role filter
+	userRole := ""
+	if len(args) == 2 {
+		userRole = args[1]
+	}
 
 	contractIdList, ok := getUserContractList(stub, userId)
 	if !ok {
@@ -904,17 +1158,24 @@ func getNotificationStatus(stub shim.ChaincodeStubInterface, args []string) ([]b
 
 	for _, element := range contractIdList {
 		contractId := element
-		contract, _ = getContractDetails(stub, contractId)
+		contract = getContractDetailsForUser(stub, contractId, userId, "")
 
-		if contract.ActionPendingOn == userRole {
+		pendingRole, pending := getPendingRole(contract, userId)
+		if pending && (userRole == "" || pendingRole == userRole) {
 			contractDetails = append(contractDetails, contract)
 		}
 
 	}
 	sortedDetails = contractDetails
 	sort.Sort(sortedDetails)
-	contractAsBytes, _ := json.Marshal(sortedDetails)
-	return contractAsBytes, nil
+
+	for _, element := range sortedDetails {
+		pendingRole, _ := getPendingRole(element, userId)
+		pendingActions = append(pendingActions, pendingAction{Role: pendingRole, Contract: element})
+	}
+
+	pendingActionsAsBytes, _ := json.Marshal(pendingActions)
+	return pendingActionsAsBytes, nil
 
 }
 
@@ -922,20 +1183,20 @@ func getNotificationCountStatus(stub shim.ChaincodeStubInterface, args []string)
 
 	var contract contract
 
-	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Need 2 argument")
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 or 2 argument")
 	}
 
 	userId := args[0]
-	userRole := args[1]
 
-	var countStatus countStatus
+	//Optional role filter
+	userRole := ""
+	if len(args) == 2 {
+		userRole = args[1]
+	}
 
-	var contractCount int
-	var lcCount int
-	var shipmentCount int
-	var paymnetCount int
-	var completedCount int
+	var notificationStatus notificationCountStatus
+	notificationStatus.ByRole = map[string]countStatus{}
 
 	contractIdList := []string{}
 	//contractDetails := []contract{}
@@ -949,33 +1210,37 @@ func getNotificationCountStatus(stub shim.ChaincodeStubInterface, args []string)
 	for _, element := range contractIdList {
 		contractId := element
 		contract, _ = getContractDetails(stub, contractId)
-		if contract.ActionPendingOn == userRole {
+
+		pendingRole, pending := getPendingRole(contract, userId)
+		if pending && (userRole == "" || pendingRole == userRole) {
 
 			status := mapping_status(contract.ContractStatus)
+			roleCount := notificationStatus.ByRole[pendingRole]
 
 			// Counts Check
 
 			if status == contracts {
-				contractCount++
+				notificationStatus.ContractCount++
+				roleCount.ContractCount++
 			} else if status == lc {
-				lcCount++
+				notificationStatus.LCCount++
+				roleCount.LCCount++
 			} else if status == shipment {
-				shipmentCount++
+				notificationStatus.ShipmentCount++
+				roleCount.ShipmentCount++
 			} else if status == payment {
-				paymnetCount++
+				notificationStatus.PaymentCount++
+				roleCount.PaymentCount++
 			} else if status == completed {
-				completedCount++
+				notificationStatus.CompletedCount++
+				roleCount.CompletedCount++
 			}
+
+			notificationStatus.ByRole[pendingRole] = roleCount
 		}
 	}
 
-	countStatus.ContractCount = contractCount
-	countStatus.LCCount = lcCount
-	countStatus.PaymentCount = paymnetCount
-	countStatus.ShipmentCount = shipmentCount
-	countStatus.CompletedCount = completedCount
-
-	countStatusAsBytes, _ := json.Marshal(countStatus)
+	countStatusAsBytes, _ := json.Marshal(notificationStatus)
 	return countStatusAsBytes, nil
 
 }
@@ -986,16 +1251,24 @@ func UpdateContractStatus(stub shim.ChaincodeStubInterface, args []string) ([]by
 	//var status statusMaintained
 	//var contractLists contract
 
-	if len(args) != 2 {
-		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 or 3 arguments")
 	}
 
 	userID := args[0]
 	contractID := args[1]
 	current_time := time.Now().Local()
 	contractList, _ := getContractDetails(stub, contractID)
+	previousContract := contractList
 
 	contractStatus := contractList.ContractStatus
+	if contractStatus == Contract_Blocked {
+		return nil, errors.New("Contract is blocked by sanctions screening")
+	}
+	if contractList.ScreeningStatus == Screening_Review {
+		return nil, errors.New("Contract is pending compliance review")
+	}
+
 	//for seller
 	if contractList.SellerDetails.Seller.UserId == userID {
 		if contractStatus == LC_Approved {
@@ -1064,28 +1337,223 @@ func UpdateContractStatus(stub shim.ChaincodeStubInterface, args []string) ([]by
 	contractList.LastUpdatedDate = current_time.Format("2006-01-02")
 	//status = setStructStatus(stub, status, userID, contractStatus)
 
-	//Discount logic - Ready For Shipment
-	DeliveryDate, _ := time.Parse(time.RFC3339, contractList.DeliveryDetails.DeliveryDate)
-	CurrentDate := time.Now().Local()
-	if contractList.SellerDetails.Seller.UserId == userID {
-		if contractStatus == LC_Approved {
-			if CurrentDate.After(DeliveryDate) {
-				Days := DiffDays(int(CurrentDate.Year()), int(CurrentDate.Month()), int(CurrentDate.Day()), int(DeliveryDate.Year()), int(DeliveryDate.Month()), int(DeliveryDate.Day()))
-				if (Days > 0) && (Days <= 5) {
-					contractList.DiscountPercentage = 5
-					contractList.DiscountedAmount = contractList.TotalTradeAmount - (contractList.TotalTradeAmount * 0.05)
-					//return []byte("Disscount 5%"), nil //errors.New("Disscount 5%")
-				} else if (Days >= 6) && (Days <= 15) {
-					contractList.DiscountPercentage = 10
-					contractList.DiscountedAmount = contractList.TotalTradeAmount - (contractList.TotalTradeAmount * 0.10)
-					//return []byte("Disscount 10%"), nil //errors.New("Disscount 10%")
-				} else if Days >= 16 {
-					contractList.DiscountPercentage = 20
-					contractList.DiscountedAmount = contractList.TotalTradeAmount - (contractList.TotalTradeAmount * 0.20)
-					//return []byte("Disscount 20%"), nil //errors.New("Disscount 20%")
-				}
+	//Penalty rules - where risk passes to the buyer
+	var penalties []penaltyRecord
+	if contractList.ContractStatus != contractStatus && contractList.ContractStatus == riskTransferStatus(contractList) {
+		penalties, err = evaluatePenaltyRules(contractList, contractPenaltyRules(stub, contractList), sellerHandoverDate(contractList, time.Now().Local()))
+		if err != nil {
+			return nil, err
+		}
+		contractList, err = applyPenalties(contractList, append(getPenaltyList(stub, contractID), penalties...))
+		if err != nil {
+			return nil, err
+		}
+		if contractList.RiskTransfer != nil {
+			riskTerms := *contractList.RiskTransfer
+			riskTerms.RiskTransferredDate = current_time.Format("2006-01-02")
+			contractList.RiskTransfer = &riskTerms
+		}
+	}
+
+	//Documents the Incoterm puts on each side
+	if contractList.ContractStatus != contractStatus {
+		err = checkTradeDocuments(contractList)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//Signature on transitions that carry legal weight
+	var signature contractSignature
+	signatureRequired := contractList.ContractStatus != contractStatus && Signature_Required_Status[contractList.ContractStatus]
+	if signatureRequired {
+		if len(args) != 3 {
+			return nil, errors.New("Signature required for " + contractList.ContractStatus)
+		}
+		var signErr error
+		signature, signErr = verifyContractVersionSignature(stub, previousContract, userID, contractList.ContractStatus, args[2])
+		if signErr != nil {
+			return nil, signErr
+		}
+	}
+
+	//Letter of credit terms
+	var lcDetails letterOfCredit
+	lcStage := contractList.ContractStatus != contractStatus && (contractList.ContractStatus == LC_Created || contractList.ContractStatus == LC_Approved)
+	lcRequired := lcStage && !contractList.SecuredByStandby
+	if lcStage && contractList.SecuredByStandby {
+		err = checkStandbyCover(stub, contractList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if lcRequired {
+		var lcErr error
+		lcDetails, lcErr = checkLetterOfCreditTerms(stub, contractList)
+		if lcErr != nil {
+			return nil, lcErr
+		}
+		if contractList.ContractStatus == LC_Created {
+			lcErr = checkCreditLimit(stub, lcDetails.IssuingBank, lcDetails.Applicant, creditExposureAmount(lcDetails), lcDetails.LCNumber)
+			if lcErr != nil {
+				return nil, lcErr
+			}
+		}
+	}
+
+	//Goods are only delivered against the surrendered bill of lading
+	if contractList.ContractStatus != contractStatus && contractList.ContractStatus == Shipment_Delivered {
+		blDetails, found := getBillOfLadingDetails(stub, contractList.BLNumber)
+		if contractList.BLNumber == "" || !found || blDetails.Status != BL_Status_Surrendered {
+			return nil, errors.New("Bill of lading must be surrendered before " + Shipment_Delivered)
+		}
+
+		//The buyer confirms what arrived, lines it leaves out are delivered in full
+		var lines []invoiceLineRequest
+		if len(args) == 3 {
+			err = json.Unmarshal([]byte(args[2]), &lines)
+			if err != nil {
+				return nil, errors.New("Invalid delivered quantities")
+			}
+		}
+		contractList.TradeDetails, err = applyDeliveredQuantities(contractList.TradeDetails, lines, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//Multi-leg shipments start on the first leg and are received from the last
+	if contractList.ContractStatus != contractStatus && len(contractList.DeliveryDetails.TransportLegs) != 0 {
+		legs, legErr := advanceTransportLegs(contractList, current_time.Format("2006-01-02"))
+		if legErr != nil {
+			return nil, legErr
+		}
+		contractList.DeliveryDetails.TransportLegs = legs
+	}
+
+	//Invoice must be issued before Invoice Created
+	if contractList.ContractStatus != contractStatus && contractList.ContractStatus == Invoice_Created {
+		invoiceList := getContractInvoiceList(stub, contractList, Invoice_Type_Invoice)
+		if len(invoiceList) == 0 {
+			return nil, errors.New("Invoice must be issued before " + Invoice_Created)
+		}
+		contractList.PaymentDueDate = invoiceList[0].DueDate
+	}
 
+	//Payment details on payment transitions
+	var paymentDetails paymentRecord
+	paymentRequired := contractList.ContractStatus != contractStatus && (contractList.ContractStatus == Payment_Completed_to_Seller || contractList.ContractStatus == Payment_Completed_to_Seller_Bank)
+	if paymentRequired {
+		if len(args) != 3 {
+			return nil, errors.New("Payment details required for " + contractList.ContractStatus)
+		}
+		var paymentErr error
+		paymentDetails, paymentErr = parsePaymentDetails(stub, contractList, userID, args[2])
+		if paymentErr != nil {
+			return nil, paymentErr
+		}
+		paymentErr = checkSettlementFunds(stub, paymentDetails.PayerId, paymentDetails.Amount)
+		if paymentErr != nil {
+			return nil, paymentErr
+		}
+	}
+
+	//Sanctions screening on status change
+	if contractList.ContractStatus != contractStatus {
+		screening := screenContract(stub, contractList, contractList.ContractStatus)
+		ok = recordScreeningResult(stub, screening)
+		if !ok {
+			return nil, errors.New("Error in recording screening result")
+		}
+
+		//A transition held by screening has no other effect
+		if screening.Result != Screening_Clear {
+			contractList = applyScreeningResult(previousContract, screening)
+			contractList.LastUpdatedDate = current_time.Format("2006-01-02")
+			ok = updateContractListByContractID(stub, contractID, contractList)
+			if !ok {
+				return nil, errors.New("Error in updating contract list")
 			}
+			return nil, nil
+		}
+		contractList = applyScreeningResult(contractList, screening)
+	}
+	transitioned := contractList.ContractStatus != contractStatus
+
+	if lcRequired && contractList.ContractStatus == LC_Approved {
+		lcDetails.Status = LC_Status_Advised
+		contractList.IsLCAttached = true
+		ok = updateLetterOfCreditDetails(stub, lcDetails)
+		if !ok {
+			return nil, errors.New("Error in updating letter of credit")
+		}
+	}
+
+	if lcRequired && contractList.ContractStatus == LC_Created {
+		ok = openCreditExposure(stub, lcDetails)
+		if !ok {
+			return nil, errors.New("Error in updating credit exposure")
+		}
+	}
+
+	//Bank fees on the transition
+	if lcRequired && transitioned {
+		feeBank := lcDetails.IssuingBank
+		feeEvent := Fee_LCIssuance
+		if contractList.ContractStatus == LC_Approved {
+			feeBank = lcDetails.AdvisingBank
+			feeEvent = Fee_LCAdvising
+		}
+		ok = chargeBankFee(stub, contractList, feeBank, feeEvent, lcDetails.Amount, lcDetails.LCNumber)
+		if !ok {
+			return nil, errors.New("Error in charging bank fees")
+		}
+	}
+	if paymentRequired && transitioned {
+		ok = chargeBankFee(stub, contractList, paymentDetails.PayerId, Fee_Payment, paymentDetails.Amount, paymentDetails.BankReference)
+		if !ok {
+			return nil, errors.New("Error in charging bank fees")
+		}
+	}
+
+	if paymentRequired && contractList.ContractStatus == Payment_Completed_to_Seller_Bank {
+		ok = closeCreditExposure(stub, contractList.BuyerDetails.BuyerBank.UserId, contractList.LCNumber, Exposure_Settled)
+		if !ok {
+			return nil, errors.New("Error in updating credit exposure")
+		}
+	}
+
+	if len(penalties) != 0 && transitioned {
+		ok = updatePenaltyList(stub, contractID, append(getPenaltyList(stub, contractID), penalties...))
+		if !ok {
+			return nil, errors.New("Error in recording penalties")
+		}
+	}
+
+	if transitioned {
+		ok = triggerMilestones(stub, contractList, getMilestoneStatusList(stub, contractID))
+		if !ok {
+			return nil, errors.New("Error in updating payment schedule")
+		}
+	}
+
+	//Payment and status change commit together
+	if paymentRequired && transitioned {
+		err = transferSettlementFunds(stub, paymentDetails.PayerId, paymentDetails.PayeeId, paymentDetails.Amount)
+		if err != nil {
+			return nil, err
+		}
+		paymentDetails.Settled = true
+		ok = updatePaymentList(stub, contractID, append(getPaymentList(stub, contractID), paymentDetails))
+		if !ok {
+			return nil, errors.New("Error in recording payment")
+		}
+	}
+
+	if signatureRequired && transitioned {
+		ok = recordContractSignature(stub, previousContract, signature)
+		if !ok {
+			return nil, errors.New("Error in recording contract signature")
 		}
 	}
 
@@ -1097,6 +1565,4593 @@ func UpdateContractStatus(stub shim.ChaincodeStubInterface, args []string) ([]by
 	return nil, err
 }
 
+func contractParties(contractDetails contract) map[string]user {
+	return map[string]user{
+		Party_Seller:      contractDetails.SellerDetails.Seller,
+		Party_SellerBank:  contractDetails.SellerDetails.SellerBank,
+		Party_Buyer:       contractDetails.BuyerDetails.Buyer,
+		Party_BuyerBank:   contractDetails.BuyerDetails.BuyerBank,
+		Party_Transporter: contractDetails.DeliveryDetails.TransporterDetails,
+	}
+}
+
+// getContractRoles returns every party role the user holds on the contract
+func getContractRoles(contractDetails contract, userId string) []string {
+	var roles []string
+	parties := contractParties(contractDetails)
+	for _, role := range Party_Roles {
+		if parties[role].UserId == userId {
+			roles = append(roles, role)
+		}
+	}
+	//Later leg carriers can read the contract but never hold the transporter's pending actions
+	if parties[Party_Transporter].UserId != userId && isLegTransporter(contractDetails, userId) {
+		roles = append(roles, Party_LegTransporter)
+	}
+	return roles
+}
+
+func getPendingRole(contractDetails contract, userId string) (string, bool) {
+	for _, role := range getContractRoles(contractDetails, userId) {
+		if contractDetails.ActionPendingOn == role {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func hasUserRole(stub shim.ChaincodeStubInterface, userId string, role string) bool {
+	for _, element := range getUserRoles(stub, userId) {
+		if element == role {
+			return true
+		}
+	}
+	return false
+}
+
+func assignUserRole(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	adminId := args[0]
+	userId := args[1]
+	role := args[2]
+
+	if !hasUserRole(stub, adminId, Role_Admin) {
+		return nil, errors.New("Only admin can assign user roles")
+	}
+	if hasUserRole(stub, userId, role) {
+		return nil, nil
+	}
+
+	roleList := append(getUserRoles(stub, userId), role)
+	ok := updateUserRoles(stub, userId, roleList)
+	if !ok {
+		return nil, errors.New("Error in updating user roles")
+	}
+
+	return nil, nil
+}
+
+func importWatchList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var entries []watchListEntry
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	version, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, errors.New("Watch list version must be a number")
+	}
+
+	if !hasUserRole(stub, userId, Role_Admin) {
+		return nil, errors.New("Only admin can import the watch list")
+	}
+
+	currentList, found := getWatchListDetails(stub, "latest")
+	if found && version <= currentList.Version {
+		return nil, errors.New("Watch list version must be greater than " + strconv.Itoa(currentList.Version))
+	}
+
+	err = json.Unmarshal([]byte(args[2]), &entries)
+	if err != nil {
+		return nil, errors.New("Invalid watch list entries")
+	}
+
+	for _, element := range entries {
+		if element.EntryType != WatchList_Name && element.EntryType != WatchList_Country && element.EntryType != WatchList_Identifier {
+			return nil, errors.New("Invalid watch list entry type " + element.EntryType)
+		} else if element.Action != WatchList_Action_Block && element.Action != WatchList_Action_Review {
+			return nil, errors.New("Invalid watch list action " + element.Action)
+		} else if normaliseScreeningValue(element.Value) == "" {
+			return nil, errors.New("Watch list entry value is mandatory")
+		}
+	}
+
+	newList := watchList{
+		Version:    version,
+		ImportedBy: userId,
+		ImportDate: time.Now().Local().Format(dateFormat),
+		Entries:    entries,
+	}
+
+	//Keep every version and point latest to the new one
+	ok := updateWatchListDetails(stub, strconv.Itoa(version), newList)
+	if !ok {
+		return nil, errors.New("Error in saving watch list")
+	}
+	ok = updateWatchListDetails(stub, "latest", newList)
+	if !ok {
+		return nil, errors.New("Error in saving watch list")
+	}
+
+	return nil, nil
+}
+
+func getWatchList(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) > 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 0 or 1 argument")
+	}
+
+	version := "latest"
+	if len(args) == 1 {
+		version = args[0]
+	}
+
+	watchListDetails, found := getWatchListDetails(stub, version)
+	if !found {
+		return nil, errors.New("Watch list version " + version + " not found")
+	}
+
+	jsonAsBytes, _ := json.Marshal(watchListDetails)
+	return jsonAsBytes, nil
+}
+
+func normaliseScreeningValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, value)
+	return strings.ToUpper(strings.Join(strings.Fields(value), " "))
+}
+
+func screenContract(stub shim.ChaincodeStubInterface, contractDetails contract, stage string) screeningResult {
+	var result screeningResult
+
+	result.ContractId = contractDetails.ContractId
+	result.Stage = stage
+	result.ScreenDate = time.Now().Local().Format(dateFormat)
+	result.Result = Screening_Clear
+
+	currentList, found := getWatchListDetails(stub, "latest")
+	if !found {
+		return result
+	}
+	result.WatchListVersion = currentList.Version
+
+	//Fixed orders so every peer records the same matches
+	parties := contractParties(contractDetails)
+	addresses := [][2]string{
+		{"pickupAddress", contractDetails.DeliveryDetails.PickupAddress},
+		{"deliveryAddress", contractDetails.DeliveryDetails.DeliveryAddress},
+	}
+
+	for _, entry := range currentList.Entries {
+		value := normaliseScreeningValue(entry.Value)
+
+		for _, party := range Party_Roles {
+			details := parties[party]
+			if entry.EntryType == WatchList_Identifier && normaliseScreeningValue(details.UserId) == value {
+				result.Matches = append(result.Matches, screeningMatch{party, "userId", details.UserId, entry.EntryType, entry.ListName, entry.Action})
+			} else if entry.EntryType == WatchList_Name && normaliseScreeningValue(details.UserName) == value {
+				result.Matches = append(result.Matches, screeningMatch{party, "userName", details.UserName, entry.EntryType, entry.ListName, entry.Action})
+			} else if entry.EntryType == WatchList_Country && strings.Contains(" "+normaliseScreeningValue(details.Address)+" ", " "+value+" ") {
+				result.Matches = append(result.Matches, screeningMatch{party, "address", details.Address, entry.EntryType, entry.ListName, entry.Action})
+			}
+		}
+
+		if entry.EntryType == WatchList_Country {
+			for _, address := range addresses {
+				if strings.Contains(" "+normaliseScreeningValue(address[1])+" ", " "+value+" ") {
+					result.Matches = append(result.Matches, screeningMatch{"delivery", address[0], address[1], entry.EntryType, entry.ListName, entry.Action})
+				}
+			}
+		}
+	}
+
+	for _, match := range result.Matches {
+		if match.Action == WatchList_Action_Block {
+			result.Result = Screening_Blocked
+			break
+		}
+		result.Result = Screening_Review
+	}
+
+	return result
+}
+
+func applyScreeningResult(contractDetails contract, result screeningResult) contract {
+	contractDetails.ScreeningStatus = result.Result
+	if result.Result == Screening_Blocked {
+		contractDetails.ContractStatus = Contract_Blocked
+		contractDetails.ActionPendingOn = Contract_Blocked
+	}
+	return contractDetails
+}
+
+func recordScreeningResult(stub shim.ChaincodeStubInterface, result screeningResult) bool {
+	screeningList := getScreeningList(stub, result.ContractId)
+	screeningList = append(screeningList, result)
+	return updateScreeningList(stub, result.ContractId, screeningList)
+}
+
+func resolveScreeningReview(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	decision := args[2]
+
+	if !hasUserRole(stub, userId, Role_Admin) && !hasUserRole(stub, userId, Role_Compliance) {
+		return nil, errors.New("Only admin or compliance can resolve a screening review")
+	}
+	if decision != Screening_Clear && decision != Screening_Blocked {
+		return nil, errors.New("Decision must be " + Screening_Clear + " or " + Screening_Blocked)
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.ScreeningStatus != Screening_Review {
+		return nil, errors.New("Contract is not pending compliance review")
+	}
+
+	result := screeningResult{
+		ContractId: contractId,
+		Stage:      "Manual Review",
+		ScreenDate: time.Now().Local().Format(dateFormat),
+		Result:     decision,
+		ReviewedBy: userId,
+	}
+	currentList, found := getWatchListDetails(stub, "latest")
+	if found {
+		result.WatchListVersion = currentList.Version
+	}
+
+	contractDetails = applyScreeningResult(contractDetails, result)
+	contractDetails.LastUpdatedDate = result.ScreenDate
+
+	ok := recordScreeningResult(stub, result)
+	if !ok {
+		return nil, errors.New("Error in recording screening result")
+	}
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func getConfigurationByVersion(stub shim.ChaincodeStubInterface, version int) configuration {
+	for _, element := range getConfigurationHistory(stub) {
+		if element.Version == version {
+			return element
+		}
+	}
+	return defaultConfiguration()
+}
+
+func getEffectiveConfiguration(stub shim.ChaincodeStubInterface, date time.Time) configuration {
+	effective := defaultConfiguration()
+	day := date.Format(dateFormat)
+
+	//Latest effective-from date wins, then the higher version
+	for _, element := range getConfigurationHistory(stub) {
+		if element.EffectiveFrom > day {
+			continue
+		}
+		if element.EffectiveFrom > effective.EffectiveFrom ||
+			(element.EffectiveFrom == effective.EffectiveFrom && element.Version > effective.Version) {
+			effective = element
+		}
+	}
+	return effective
+}
+
+func validateConfiguration(config configuration) (bool, error) {
+	if config.MinDaysPaymentDuration < 0 || config.MinDaysPaymentDuration > config.MaxDaysPaymentDuration {
+		return false, errors.New("Payment duration limits are invalid")
+	} else if config.MinDaysTransportDuration < 0 || config.MinDaysTransportDuration > config.MaxDaysTransportDuration {
+		return false, errors.New("Transport duration limits are invalid")
+	} else if config.MinDaysDeliveryDuration < 0 || config.MinDaysDeliveryDuration > config.MaxDaysDeliveryDuration {
+		return false, errors.New("Delivery duration limits are invalid")
+	} else if config.LatestContractsCount <= 0 {
+		return false, errors.New("Latest contracts count must be greater than 0")
+	}
+
+	return validateDiscountTiers(config.LateDeliveryDiscountTiers)
+}
+
+func checkContractDurations(contractDetails contract, config configuration) (bool, error) {
+	paymentDuration, err := strconv.Atoi(contractDetails.TradeConditions.PaymentDuration)
+	if err != nil {
+		return false, errors.New("Payment duration must be a number of days")
+	} else if paymentDuration < config.MinDaysPaymentDuration || paymentDuration > config.MaxDaysPaymentDuration {
+		return false, errors.New("Payment duration must be between " + strconv.Itoa(config.MinDaysPaymentDuration) + " and " + strconv.Itoa(config.MaxDaysPaymentDuration) + " days")
+	}
+
+	transportDuration, err := strconv.Atoi(contractDetails.TradeConditions.TransportDuration)
+	if err != nil {
+		return false, errors.New("Transport duration must be a number of days")
+	} else if transportDuration < config.MinDaysTransportDuration || transportDuration > config.MaxDaysTransportDuration {
+		return false, errors.New("Transport duration must be between " + strconv.Itoa(config.MinDaysTransportDuration) + " and " + strconv.Itoa(config.MaxDaysTransportDuration) + " days")
+	}
+
+	//Delivery duration runs from the create date to the agreed delivery date
+	deliveryDate, err := time.Parse(time.RFC3339, contractDetails.DeliveryDetails.DeliveryDate)
+	if err != nil {
+		return false, errors.New("Delivery date must be in RFC3339 format")
+	}
+	createDate := contractDetails.ContractCreateDate
+	deliveryDuration := DiffDays(deliveryDate.Year(), int(deliveryDate.Month()), deliveryDate.Day(), createDate.Year(), int(createDate.Month()), createDate.Day())
+	if deliveryDuration < config.MinDaysDeliveryDuration || deliveryDuration > config.MaxDaysDeliveryDuration {
+		return false, errors.New("Delivery date must be between " + strconv.Itoa(config.MinDaysDeliveryDuration) + " and " + strconv.Itoa(config.MaxDaysDeliveryDuration) + " days after the contract date")
+	}
+
+	return true, nil
+}
+
+func validateDiscountTiers(tiers []discountTier) (bool, error) {
+	previousMaxDays := 0
+	for i, tier := range tiers {
+		if tier.Percentage < 0 || tier.Percentage > 100 {
+			return false, errors.New("Discount percentage must be between 0 and 100")
+		} else if tier.MinDays <= previousMaxDays {
+			return false, errors.New("Discount tiers must be in ascending order without overlap")
+		} else if tier.MaxDays == 0 && i != len(tiers)-1 {
+			return false, errors.New("Only the last discount tier can be open ended")
+		} else if tier.MaxDays != 0 && tier.MaxDays < tier.MinDays {
+			return false, errors.New("Discount tier max days must not be less than min days")
+		}
+		previousMaxDays = tier.MaxDays
+	}
+
+	return true, nil
+}
+
+func setConfiguration(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var newConfig configuration
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	effectiveFrom, err := time.Parse(dateFormat, args[1])
+	if err != nil {
+		return nil, errors.New("Effective from date must be in " + dateFormat + " format")
+	}
+
+	if !hasUserRole(stub, userId, Role_Admin) {
+		return nil, errors.New("Only admin can change the configuration")
+	}
+
+	//Back dated changes are not allowed
+	today := time.Now().Local().Format(dateFormat)
+	if effectiveFrom.Format(dateFormat) < today {
+		return nil, errors.New("Effective from date must not be in the past")
+	}
+
+	err = json.Unmarshal([]byte(args[2]), &newConfig)
+	if err != nil {
+		return nil, errors.New("Invalid configuration")
+	}
+
+	ok, err := validateConfiguration(newConfig)
+	if !ok {
+		return nil, err
+	}
+
+	configurationList := getConfigurationHistory(stub)
+	newConfig.Version = len(configurationList) + 1
+	newConfig.EffectiveFrom = effectiveFrom.Format(dateFormat)
+	newConfig.ChangedBy = userId
+	newConfig.ChangeDate = today
+
+	configurationList = append(configurationList, newConfig)
+	ok = updateConfigurationHistory(stub, configurationList)
+	if !ok {
+		return nil, errors.New("Error in saving configuration")
+	}
+
+	return nil, nil
+}
+
+func getConfiguration(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) > 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 0 or 1 argument")
+	}
+
+	date := time.Now().Local()
+	if len(args) == 1 {
+		var err error
+		date, err = time.Parse(dateFormat, args[0])
+		if err != nil {
+			return nil, errors.New("Date must be in " + dateFormat + " format")
+		}
+	}
+
+	jsonAsBytes, _ := json.Marshal(getEffectiveConfiguration(stub, date))
+	return jsonAsBytes, nil
+}
+
+func getConfigurationChanges(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 0 {
+		return nil, errors.New("Incorrect number of arguments. Need 0 argument")
+	}
+
+	configurationList := append([]configuration{defaultConfiguration()}, getConfigurationHistory(stub)...)
+	jsonAsBytes, _ := json.Marshal(configurationList)
+	return jsonAsBytes, nil
+}
+
+func isPricingParty(contractDetails contract, userId string) bool {
+	return userId != "" && (contractDetails.SellerDetails.Seller.UserId == userId ||
+		contractDetails.SellerDetails.SellerBank.UserId == userId ||
+		contractDetails.BuyerDetails.Buyer.UserId == userId ||
+		contractDetails.BuyerDetails.BuyerBank.UserId == userId)
+}
+
+// Pricing privacy and its limits.
+// Protected: contract responses, contract lists and the public signing hash carry only the pricing hash, salted
+// with a random salt the client supplies, and pricing is only returned to a trading party that signs the day's
+// pricing access hash with its registered key.
+// Not protected: saveContract receives the prices and the salt in its arguments and the pricing row is plain
+// world state, so peers and anyone who can read transactions or world state see them unless the network runs
+// with confidentiality enabled. The chaincode needs plaintext pricing for invoices, penalties, fees and payments,
+// and the invoice, payment and fee queries still trust the userId they are given.
+
+func checkPricingSalt(pricingSalt string) (bool, error) {
+	salt, err := hex.DecodeString(pricingSalt)
+	if err != nil || len(salt) < 16 {
+		return false, errors.New("Pricing salt must be at least 16 random bytes, hex encoded")
+	}
+	return true, nil
+}
+
+// redactContractPricing replaces pricing with the hash already on the contract
+func redactContractPricing(contractDetails contract) contract {
+	pricingHash := contractDetails.PricingHash
+	contractDetails, _ = splitContractPricing(contractDetails, "")
+	contractDetails.PricingHash = pricingHash
+	return contractDetails
+}
+
+// pricingAccessHash is what a trading party signs to read pricing, it is valid for one day
+func pricingAccessHash(userId string, accessDate string) []byte {
+	hash := sha256.Sum256([]byte("pricingAccess|" + userId + "|" + accessDate))
+	return hash[:]
+}
+
+func hasPricingAccess(stub shim.ChaincodeStubInterface, contractDetails contract, userId string, signatureAsBase64 string) bool {
+	return isPricingParty(contractDetails, userId) && verifyPricingAccess(stub, userId, signatureAsBase64)
+}
+
+func verifyPricingAccess(stub shim.ChaincodeStubInterface, userId string, signatureAsBase64 string) bool {
+	if signatureAsBase64 == "" {
+		return false
+	}
+
+	signerKey, found := getActivePublicKey(stub, userId)
+	if !found {
+		return false
+	}
+	signatureAsBytes, err := base64.StdEncoding.DecodeString(signatureAsBase64)
+	if err != nil {
+		return false
+	}
+	return verifySignature(signerKey.PublicKey, pricingAccessHash(userId, time.Now().Local().Format(dateFormat)), signatureAsBytes)
+}
+
+func getContractDetailsForUser(stub shim.ChaincodeStubInterface, contractId string, userId string, signature string) contract {
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if hasPricingAccess(stub, contractDetails, userId, signature) {
+		return contractDetails
+	}
+	return redactContractPricing(contractDetails)
+}
+
+func getPricingAccessHash(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	access := pricingAccess{
+		UserId:     args[0],
+		AccessDate: time.Now().Local().Format(dateFormat),
+	}
+	access.AccessHash = hex.EncodeToString(pricingAccessHash(access.UserId, access.AccessDate))
+
+	jsonAsBytes, _ := json.Marshal(access)
+	return jsonAsBytes, nil
+}
+
+func getContractPricing(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+	signature := args[2]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !hasPricingAccess(stub, contractDetails, userId, signature) {
+		return nil, errors.New("Only buyer, seller and their banks can read contract pricing, with a signed pricing access hash")
+	}
+
+	pricing, found := getContractPricingDetails(stub, contractId)
+	if !found {
+		return nil, errors.New("Contract pricing not found")
+	}
+
+	jsonAsBytes, _ := json.Marshal(pricing)
+	return jsonAsBytes, nil
+}
+
+func verifyContractPricing(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var pricing contractPricing
+
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	err := json.Unmarshal([]byte(args[1]), &pricing)
+	if err != nil {
+		return nil, errors.New("Invalid contract pricing")
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	pricing.ContractId = contractId
+	valid := contractDetails.PricingHash != "" && hashContractPricing(pricing) == contractDetails.PricingHash
+
+	jsonAsBytes, _ := json.Marshal(valid)
+	return jsonAsBytes, nil
+}
+
+// canonicalContractHash hashes the contract with its pricing replaced by the salted pricing hash, so the public
+// signing hash does not give away the prices
+func canonicalContractHash(contractDetails contract) ([]byte, []byte) {
+	contractAsBytes, _ := json.Marshal(redactContractPricing(contractDetails))
+	hash := sha256.Sum256(contractAsBytes)
+	return hash[:], contractAsBytes
+}
+
+func parsePublicKey(publicKeyPEM string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.New("Public key must be PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("Invalid public key")
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	}
+	return nil, errors.New("Only ECDSA and RSA public keys are supported")
+}
+
+func verifySignature(publicKeyPEM string, hash []byte, signature []byte) bool {
+	key, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return false
+	}
+
+	switch publicKey := key.(type) {
+	case *ecdsa.PublicKey:
+		var ecdsaSignature struct {
+			R, S *big.Int
+		}
+		_, err = asn1.Unmarshal(signature, &ecdsaSignature)
+		if err != nil {
+			return false
+		}
+		return ecdsa.Verify(publicKey, hash, ecdsaSignature.R, ecdsaSignature.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash, signature) == nil
+	}
+	return false
+}
+
+func getActivePublicKey(stub shim.ChaincodeStubInterface, userId string) (publicKey, bool) {
+	for _, element := range getPublicKeyList(stub, userId) {
+		if element.Active {
+			return element, true
+		}
+	}
+	return publicKey{}, false
+}
+
+func verifyContractVersionSignature(stub shim.ChaincodeStubInterface, contractDetails contract, userId string, status string, signatureAsBase64 string) (contractSignature, error) {
+	var signature contractSignature
+
+	signerKey, found := getActivePublicKey(stub, userId)
+	if !found {
+		return signature, errors.New("No public key registered for " + userId)
+	}
+
+	signatureAsBytes, err := base64.StdEncoding.DecodeString(signatureAsBase64)
+	if err != nil {
+		return signature, errors.New("Signature must be base64 encoded")
+	}
+
+	hash, _ := canonicalContractHash(contractDetails)
+	if !verifySignature(signerKey.PublicKey, hash, signatureAsBytes) {
+		return signature, errors.New("Signature does not match contract version " + strconv.Itoa(contractDetails.ContractVersion))
+	}
+
+	signature.ContractId = contractDetails.ContractId
+	signature.ContractVersion = contractDetails.ContractVersion
+	signature.ContractHash = hex.EncodeToString(hash)
+	signature.UserId = userId
+	signature.Role = contractDetails.ActionPendingOn
+	signature.Status = status
+	signature.Signature = signatureAsBase64
+	signature.SignedDate = time.Now().Local().Format(dateFormat)
+
+	return signature, nil
+}
+
+func recordContractSignature(stub shim.ChaincodeStubInterface, contractDetails contract, signature contractSignature) bool {
+	_, contractAsBytes := canonicalContractHash(contractDetails)
+	ok := insertContractVersion(stub, contractDetails.ContractId, contractDetails.ContractVersion, contractAsBytes)
+	if !ok {
+		return false
+	}
+
+	signatureList := getSignatureList(stub, contractDetails.ContractId)
+	signatureList = append(signatureList, signature)
+	return updateSignatureList(stub, contractDetails.ContractId, signatureList)
+}
+
+func registerPublicKey(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 or 4 arguments")
+	}
+
+	callerId := args[0]
+	userId := args[1]
+	publicKeyPEM := args[2]
+
+	_, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	//The first key is registered by the admin, replacing it needs a signature over the new key by the current one
+	currentKey, found := getActivePublicKey(stub, userId)
+	if !found {
+		if !hasUserRole(stub, callerId, Role_Admin) {
+			return nil, errors.New("Only the admin can register a user's first public key")
+		}
+	} else {
+		if callerId != userId {
+			return nil, errors.New("Only " + userId + " can replace their public key")
+		} else if len(args) != 4 {
+			return nil, errors.New("Signature by the current key required to replace it")
+		}
+		signatureAsBytes, err := base64.StdEncoding.DecodeString(args[3])
+		if err != nil {
+			return nil, errors.New("Signature must be base64 encoded")
+		}
+		hash := sha256.Sum256([]byte(publicKeyPEM))
+		if !verifySignature(currentKey.PublicKey, hash[:], signatureAsBytes) {
+			return nil, errors.New("Signature does not match the current public key")
+		}
+	}
+
+	//Older keys are kept so past signatures can still be checked
+	var publicKeyList []publicKey
+	for _, element := range getPublicKeyList(stub, userId) {
+		element.Active = false
+		publicKeyList = append(publicKeyList, element)
+	}
+	publicKeyList = append(publicKeyList, publicKey{
+		UserId:         userId,
+		PublicKey:      publicKeyPEM,
+		RegisteredDate: time.Now().Local().Format(dateFormat),
+		Active:         true,
+	})
+
+	ok := updatePublicKeyList(stub, userId, publicKeyList)
+	if !ok {
+		return nil, errors.New("Error in registering public key")
+	}
+
+	return nil, nil
+}
+
+func getPublicKeys(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getPublicKeyList(stub, args[0]))
+	return jsonAsBytes, nil
+}
+
+func getContractSigningHash(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	contractDetails, _ := getContractDetails(stub, args[0])
+	hash, _ := canonicalContractHash(contractDetails)
+
+	signingHash := contractSignature{
+		ContractId:      contractDetails.ContractId,
+		ContractVersion: contractDetails.ContractVersion,
+		ContractHash:    hex.EncodeToString(hash),
+	}
+	jsonAsBytes, _ := json.Marshal(signingHash)
+	return jsonAsBytes, nil
+}
+
+func getContractSignatures(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getSignatureList(stub, args[0]))
+	return jsonAsBytes, nil
+}
+
+func getSignedContractVersion(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	contractId := args[0]
+	contractVersion, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, errors.New("Contract version must be a number")
+	}
+	userId := args[2]
+
+	//Signed versions carry the pricing hash, not the pricing
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read signed contract versions")
+	}
+
+	contractAsBytes, found := getContractVersion(stub, contractId, contractVersion)
+	if !found {
+		return nil, errors.New("Signed contract version not found")
+	}
+
+	return contractAsBytes, nil
+}
+
+func publishFxRates(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var rates []fxRate
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	rateDate, err := time.Parse(dateFormat, args[1])
+	if err != nil {
+		return nil, errors.New("Rate date must be in " + dateFormat + " format")
+	}
+
+	if !hasUserRole(stub, userId, Role_RateProvider) {
+		return nil, errors.New("Only a rate provider can publish FX rates")
+	}
+
+	err = json.Unmarshal([]byte(args[2]), &rates)
+	if err != nil {
+		return nil, errors.New("Invalid FX rates")
+	}
+
+	for _, element := range rates {
+		if !isSupportedCurrency(element.FromCurrency) || !isSupportedCurrency(element.ToCurrency) {
+			return nil, errors.New("Unsupported currency pair " + element.FromCurrency + "/" + element.ToCurrency)
+		} else if element.FromCurrency == element.ToCurrency {
+			return nil, errors.New("FX rate currencies must differ")
+		}
+		rate, err := parseDecimal(element.Rate)
+		if err != nil || rate.Sign() <= 0 {
+			return nil, errors.New("Invalid FX rate for " + element.FromCurrency + "/" + element.ToCurrency)
+		}
+
+		element.RateDate = rateDate.Format(dateFormat)
+		element.PublishedBy = userId
+		element.PublishedDate = time.Now().Local().Format(dateFormat)
+		ok := updateFxRateDetails(stub, element)
+		if !ok {
+			return nil, errors.New("Error in saving FX rate")
+		}
+	}
+
+	return nil, nil
+}
+
+// findFxRate returns the rate to convert fromCurrency into toCurrency, using the inverse pair when needed
+// findFxRate uses the most recent rate on or before rateDate, the returned rate carries the date actually applied
+func findFxRate(stub shim.ChaincodeStubInterface, fromCurrency string, toCurrency string, rateDate string) (*big.Rat, fxRate, error) {
+	rate, found := getLatestFxRateDetails(stub, fromCurrency, toCurrency, rateDate)
+	inverseRate, inverseFound := getLatestFxRateDetails(stub, toCurrency, fromCurrency, rateDate)
+
+	//A later rate published the other way round wins
+	if found && (!inverseFound || rate.RateDate >= inverseRate.RateDate) {
+		value, _ := parseDecimal(rate.Rate)
+		return value, rate, nil
+	}
+	if inverseFound {
+		value, _ := parseDecimal(inverseRate.Rate)
+		return value.Inv(value), inverseRate, nil
+	}
+
+	return nil, rate, errors.New("No FX rate for " + fromCurrency + "/" + toCurrency + " on or before " + rateDate)
+}
+
+func convertMoney(stub shim.ChaincodeStubInterface, amount money, toCurrency string, rateDate string) (money, fxRate, error) {
+	if amount.Currency == toCurrency {
+		return amount, fxRate{}, nil
+	}
+
+	rate, rateDetails, err := findFxRate(stub, amount.Currency, toCurrency, rateDate)
+	if err != nil {
+		return money{Currency: toCurrency}, rateDetails, err
+	}
+	converted, err := roundMoney(new(big.Rat).Mul(amount.rat(), rate), toCurrency)
+	return converted, rateDetails, err
+}
+
+// contractTradeAmount is the amount the buyer owes after late-delivery penalties and early-delivery bonuses
+func contractTradeAmount(contractDetails contract) money {
+	amount := contractDetails.TotalTradeAmount
+	if contractDetails.DiscountPercentage != 0 {
+		amount = contractDetails.DiscountedAmount
+	}
+	if converted, err := amount.inCurrency(contractDetails.TradeConditions.Currency); err == nil {
+		amount = converted
+	}
+	return amount
+}
+
+func calculateTradeTotals(stub shim.ChaincodeStubInterface, contractList []contract, userId string, reportingCurrency string, rateDate string) (tradeTotals, error) {
+	var totals tradeTotals
+
+	if !isSupportedCurrency(reportingCurrency) {
+		return totals, errors.New("Unsupported currency " + reportingCurrency)
+	}
+	_, err := time.Parse(dateFormat, rateDate)
+	if err != nil {
+		return totals, errors.New("Rate date must be in " + dateFormat + " format")
+	}
+
+	totals.ReportingCurrency = reportingCurrency
+	totals.RateDate = rateDate
+	totals.Total = money{Currency: reportingCurrency}
+
+	byCurrency := map[string]money{}
+	var currencies []string
+	for _, element := range contractList {
+		if !isPricingParty(element, userId) {
+			continue
+		}
+		amount := contractTradeAmount(element)
+		if _, found := byCurrency[amount.Currency]; !found {
+			currencies = append(currencies, amount.Currency)
+			byCurrency[amount.Currency] = money{Currency: amount.Currency}
+		}
+		byCurrency[amount.Currency], _ = byCurrency[amount.Currency].Add(amount)
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		converted, rate, err := convertMoney(stub, byCurrency[currency], reportingCurrency, rateDate)
+		if err != nil {
+			return totals, err
+		}
+		if rate.RateDate != "" {
+			totals.RatesUsed = append(totals.RatesUsed, rate)
+		}
+		totals.ByCurrency = append(totals.ByCurrency, byCurrency[currency])
+		totals.Total, _ = totals.Total.Add(converted)
+	}
+
+	return totals, nil
+}
+
+func getTradeTotals(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var contractDetails []contract
+
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+	userId := args[0]
+
+	//Totals are pricing too
+	if !verifyPricingAccess(stub, userId, args[3]) {
+		return nil, errors.New("Trade totals need a signed pricing access hash")
+	}
+
+	contractIdList, ok := getUserContractList(stub, userId)
+	if !ok {
+		return nil, errors.New("Error in geting user specific contract list")
+	}
+	for _, element := range contractIdList {
+		contractVar, _ := getContractDetails(stub, element)
+		contractDetails = append(contractDetails, contractVar)
+	}
+
+	totals, err := calculateTradeTotals(stub, contractDetails, userId, args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	jsonAsBytes, _ := json.Marshal(totals)
+	return jsonAsBytes, nil
+}
+
+func getFxRate(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	rate, rateDetails, err := findFxRate(stub, args[0], args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	//Report the rate in the direction asked for
+	rateDetails.FromCurrency = args[0]
+	rateDetails.ToCurrency = args[1]
+	rateDetails.Rate = rate.FloatString(10)
+
+	jsonAsBytes, _ := json.Marshal(rateDetails)
+	return jsonAsBytes, nil
+}
+
+func validateLetterOfCreditTerms(lcDetails letterOfCredit, contractDetails contract) error {
+	today := time.Now().Local().Format(dateFormat)
+
+	if lcDetails.Amount.Currency != contractDetails.TradeConditions.Currency || lcDetails.Amount.Minor <= 0 {
+		return errors.New("LC amount must be a positive " + contractDetails.TradeConditions.Currency + " amount")
+	} else if lcDetails.TolerancePercentage < 0 || lcDetails.TolerancePercentage > 100 {
+		return errors.New("LC tolerance must be between 0 and 100 percent")
+	} else if lcDetails.ExpiryPlace == "" {
+		return errors.New("LC expiry place is mandatory")
+	} else if len(lcDetails.RequiredDocuments) == 0 {
+		return errors.New("LC required documents are mandatory")
+	}
+
+	_, err := time.Parse(dateFormat, lcDetails.ExpiryDate)
+	if err != nil {
+		return errors.New("LC expiry date must be in " + dateFormat + " format")
+	}
+	_, err = time.Parse(dateFormat, lcDetails.LatestShipmentDate)
+	if err != nil {
+		return errors.New("LC latest shipment date must be in " + dateFormat + " format")
+	}
+	if lcDetails.ExpiryDate < today {
+		return errors.New("LC expiry date must not be in the past")
+	} else if lcDetails.LatestShipmentDate > lcDetails.ExpiryDate {
+		return errors.New("LC latest shipment date must not be after the expiry date")
+	}
+
+	//Contract amount must fall inside the LC amount plus or minus tolerance
+	tolerance, err := lcDetails.Amount.Percentage(lcDetails.TolerancePercentage)
+	if err != nil {
+		return err
+	}
+	lowest, _ := lcDetails.Amount.Sub(tolerance)
+	highest, err := lcDetails.Amount.Add(tolerance)
+	if err != nil {
+		return err
+	}
+	tradeAmount := contractTradeAmount(contractDetails)
+	if tradeAmount.Minor < lowest.Minor || tradeAmount.Minor > highest.Minor {
+		return errors.New("Contract amount " + tradeAmount.String() + " is outside the LC amount " + lowest.String() + " to " + highest.String())
+	}
+
+	return nil
+}
+
+// expireLetterOfCreditIfDue marks the LC expired once its expiry date has passed
+func expireLetterOfCreditIfDue(lcDetails letterOfCredit) (letterOfCredit, bool) {
+	if lcDetails.Status != LC_Status_Expired && lcDetails.ExpiryDate < time.Now().Local().Format(dateFormat) {
+		lcDetails.Status = LC_Status_Expired
+		return lcDetails, true
+	}
+	return lcDetails, false
+}
+
+func checkLetterOfCreditTerms(stub shim.ChaincodeStubInterface, contractDetails contract) (letterOfCredit, error) {
+	lcDetails, found := getLetterOfCreditDetails(stub, contractDetails.LCNumber)
+	if contractDetails.LCNumber == "" || !found {
+		return lcDetails, errors.New("Letter of credit must be issued before " + contractDetails.ContractStatus)
+	}
+
+	lcDetails, _ = expireLetterOfCreditIfDue(lcDetails)
+	if lcDetails.Status != LC_Status_Issued {
+		return lcDetails, errors.New("Letter of credit " + lcDetails.LCNumber + " is " + lcDetails.Status)
+	}
+	if lcDetails.LatestShipmentDate < time.Now().Local().Format(dateFormat) {
+		return lcDetails, errors.New("Letter of credit latest shipment date has passed")
+	}
+
+	if contractDetails.ContractStatus == LC_Approved {
+		for _, element := range lcDetails.Amendments {
+			if element.Status == Amendment_Pending {
+				return lcDetails, errors.New("Letter of credit has a pending amendment")
+			}
+		}
+	}
+
+	err := validateLetterOfCreditTerms(lcDetails, contractDetails)
+	return lcDetails, err
+}
+
+func issueLetterOfCredit(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var lcDetails letterOfCredit
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.BuyerDetails.BuyerBank.UserId != userId {
+		return nil, errors.New("Only the buyer's bank can issue the letter of credit")
+	} else if contractDetails.ContractStatus != Contract_Accepted && contractDetails.ContractStatus != LC_Created {
+		return nil, errors.New("Letter of credit can only be issued for an accepted contract")
+	}
+
+	//A new LC replaces one that has expired, also once LC Created so the contract is not left without one
+	var existingLC letterOfCredit
+	replacing := false
+	if contractDetails.LCNumber != "" {
+		var found bool
+		existingLC, found = getLetterOfCreditDetails(stub, contractDetails.LCNumber)
+		existingLC, _ = expireLetterOfCreditIfDue(existingLC)
+		if found && existingLC.Status != LC_Status_Expired {
+			return nil, errors.New("Contract already has letter of credit " + existingLC.LCNumber)
+		}
+		replacing = found
+	}
+	if contractDetails.ContractStatus == LC_Created && !replacing {
+		return nil, errors.New("Letter of credit can only be reissued once the current one has expired")
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &lcDetails)
+	if err != nil {
+		return nil, errors.New("Invalid letter of credit")
+	}
+	if lcDetails.LCNumber == "" {
+		return nil, errors.New("LC number is mandatory")
+	}
+	if _, found := getLetterOfCreditDetails(stub, lcDetails.LCNumber); found {
+		return nil, errors.New("LC number " + lcDetails.LCNumber + " already exists")
+	}
+
+	lcDetails.ContractId = contractId
+	lcDetails.IssuingBank = contractDetails.BuyerDetails.BuyerBank.UserId
+	lcDetails.AdvisingBank = contractDetails.SellerDetails.SellerBank.UserId
+	lcDetails.Applicant = contractDetails.BuyerDetails.Buyer.UserId
+	lcDetails.Beneficiary = contractDetails.SellerDetails.Seller.UserId
+	lcDetails.Status = LC_Status_Issued
+	lcDetails.IssueDate = time.Now().Local().Format(dateFormat)
+	lcDetails.Amendments = nil
+
+	err = validateLetterOfCreditTerms(lcDetails, contractDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	//After LC Created the replacement takes over the expired LC's exposure and issuance fee
+	if contractDetails.ContractStatus == LC_Created {
+		err = checkCreditLimit(stub, lcDetails.IssuingBank, lcDetails.Applicant, creditExposureAmount(lcDetails), existingLC.LCNumber)
+		if err != nil {
+			return nil, err
+		}
+		if !updateLetterOfCreditDetails(stub, existingLC) || !closeCreditExposure(stub, existingLC.IssuingBank, existingLC.LCNumber, Exposure_Released) {
+			return nil, errors.New("Error in expiring letter of credit " + existingLC.LCNumber)
+		}
+		if !openCreditExposure(stub, lcDetails) {
+			return nil, errors.New("Error in updating credit exposure")
+		}
+		if !chargeBankFee(stub, contractDetails, lcDetails.IssuingBank, Fee_LCIssuance, lcDetails.Amount, lcDetails.LCNumber) {
+			return nil, errors.New("Error in charging bank fees")
+		}
+	}
+
+	ok := updateLetterOfCreditDetails(stub, lcDetails)
+	if !ok {
+		return nil, errors.New("Error in saving letter of credit")
+	}
+
+	contractDetails.LCNumber = lcDetails.LCNumber
+	contractDetails.LastUpdatedDate = lcDetails.IssueDate
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func applyLCAmendment(lcDetails letterOfCredit, amendment lcAmendment) letterOfCredit {
+	if amendment.Amount.Currency != "" {
+		lcDetails.Amount = amendment.Amount
+	}
+	if amendment.TolerancePercentage != nil {
+		lcDetails.TolerancePercentage = *amendment.TolerancePercentage
+	}
+	if amendment.ExpiryDate != "" {
+		lcDetails.ExpiryDate = amendment.ExpiryDate
+	}
+	if amendment.ExpiryPlace != "" {
+		lcDetails.ExpiryPlace = amendment.ExpiryPlace
+	}
+	if amendment.LatestShipmentDate != "" {
+		lcDetails.LatestShipmentDate = amendment.LatestShipmentDate
+	}
+	if len(amendment.RequiredDocuments) != 0 {
+		lcDetails.RequiredDocuments = amendment.RequiredDocuments
+	}
+	return lcDetails
+}
+
+func amendLetterOfCredit(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var amendment lcAmendment
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	lcNumber := args[1]
+
+	lcDetails, found := getLetterOfCreditDetails(stub, lcNumber)
+	if !found {
+		return nil, errors.New("Letter of credit " + lcNumber + " not found")
+	} else if lcDetails.IssuingBank != userId {
+		return nil, errors.New("Only the issuing bank can amend the letter of credit")
+	}
+	if _, expired := expireLetterOfCreditIfDue(lcDetails); expired || lcDetails.Status == LC_Status_Expired {
+		return nil, errors.New("Letter of credit " + lcNumber + " has expired")
+	}
+	for _, element := range lcDetails.Amendments {
+		if element.Status == Amendment_Pending {
+			return nil, errors.New("Letter of credit already has a pending amendment")
+		}
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &amendment)
+	if err != nil {
+		return nil, errors.New("Invalid letter of credit amendment")
+	}
+
+	//Amended terms must still be valid for the contract
+	contractDetails, _ := getContractDetails(stub, lcDetails.ContractId)
+	err = validateLetterOfCreditTerms(applyLCAmendment(lcDetails, amendment), contractDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	amendment.AmendmentNumber = len(lcDetails.Amendments) + 1
+	amendment.Status = Amendment_Pending
+	amendment.RequestDate = time.Now().Local().Format(dateFormat)
+	amendment.ResponseDate = ""
+	lcDetails.Amendments = append(lcDetails.Amendments, amendment)
+
+	ok := updateLetterOfCreditDetails(stub, lcDetails)
+	if !ok {
+		return nil, errors.New("Error in updating letter of credit")
+	}
+
+	return nil, nil
+}
+
+func respondLCAmendment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	lcNumber := args[1]
+	amendmentNumber, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, errors.New("Amendment number must be a number")
+	}
+	decision := args[3]
+
+	if decision != Amendment_Accepted && decision != Amendment_Rejected {
+		return nil, errors.New("Decision must be " + Amendment_Accepted + " or " + Amendment_Rejected)
+	}
+
+	lcDetails, found := getLetterOfCreditDetails(stub, lcNumber)
+	if !found {
+		return nil, errors.New("Letter of credit " + lcNumber + " not found")
+	} else if lcDetails.AdvisingBank != userId {
+		return nil, errors.New("Only the advising bank can respond to an amendment")
+	} else if amendmentNumber < 1 || amendmentNumber > len(lcDetails.Amendments) {
+		return nil, errors.New("Amendment " + args[2] + " not found")
+	}
+
+	amendment := lcDetails.Amendments[amendmentNumber-1]
+	if amendment.Status != Amendment_Pending {
+		return nil, errors.New("Amendment " + args[2] + " is already " + amendment.Status)
+	}
+
+	if decision == Amendment_Accepted {
+		contractDetails, _ := getContractDetails(stub, lcDetails.ContractId)
+		amendedLC := applyLCAmendment(lcDetails, amendment)
+		err = validateLetterOfCreditTerms(amendedLC, contractDetails)
+		if err != nil {
+			return nil, err
+		}
+		lcDetails = amendedLC
+
+		//Open exposure follows the amended LC
+		if hasOpenCreditExposure(stub, lcDetails.IssuingBank, lcNumber) {
+			err = checkCreditLimit(stub, lcDetails.IssuingBank, lcDetails.Applicant, creditExposureAmount(lcDetails), lcNumber)
+			if err != nil {
+				return nil, err
+			}
+			ok := openCreditExposure(stub, lcDetails)
+			if !ok {
+				return nil, errors.New("Error in updating credit exposure")
+			}
+		}
+	}
+
+	amendment.Status = decision
+	amendment.ResponseDate = time.Now().Local().Format(dateFormat)
+	lcDetails.Amendments[amendmentNumber-1] = amendment
+
+	if decision == Amendment_Accepted {
+		contractDetails, _ := getContractDetails(stub, lcDetails.ContractId)
+		ok := chargeBankFee(stub, contractDetails, lcDetails.IssuingBank, Fee_LCAmendment, lcDetails.Amount, lcNumber+"/"+args[2])
+		if !ok {
+			return nil, errors.New("Error in charging bank fees")
+		}
+	}
+
+	ok := updateLetterOfCreditDetails(stub, lcDetails)
+	if !ok {
+		return nil, errors.New("Error in updating letter of credit")
+	}
+
+	return nil, nil
+}
+
+func expireLetterOfCredit(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	lcDetails, found := getLetterOfCreditDetails(stub, args[0])
+	if !found {
+		return nil, errors.New("Letter of credit " + args[0] + " not found")
+	}
+
+	lcDetails, expired := expireLetterOfCreditIfDue(lcDetails)
+	if !expired {
+		return nil, errors.New("Letter of credit " + args[0] + " is not due to expire")
+	}
+
+	ok := updateLetterOfCreditDetails(stub, lcDetails)
+	if !ok {
+		return nil, errors.New("Error in updating letter of credit")
+	}
+
+	ok = closeCreditExposure(stub, lcDetails.IssuingBank, lcDetails.LCNumber, Exposure_Released)
+	if !ok {
+		return nil, errors.New("Error in updating credit exposure")
+	}
+
+	return nil, nil
+}
+
+func getLetterOfCredit(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	lcNumber := args[0]
+	userId := args[1]
+
+	lcDetails, found := getLetterOfCreditDetails(stub, lcNumber)
+	if !found {
+		return nil, errors.New("Letter of credit " + lcNumber + " not found")
+	}
+	if userId != lcDetails.IssuingBank && userId != lcDetails.AdvisingBank && userId != lcDetails.Applicant && userId != lcDetails.Beneficiary {
+		return nil, errors.New("Only the LC parties can read the letter of credit")
+	}
+
+	lcDetails, _ = expireLetterOfCreditIfDue(lcDetails)
+	jsonAsBytes, _ := json.Marshal(lcDetails)
+	return jsonAsBytes, nil
+}
+
+func validatePenaltyRules(rules []penaltyRule) (bool, error) {
+	for _, rule := range rules {
+		if rule.RuleId == "" {
+			return false, errors.New("Penalty rule id is mandatory")
+		} else if rule.GraceDays < 0 {
+			return false, errors.New("Grace days must not be negative in rule " + rule.RuleId)
+		} else if rule.CapPercentage < 0 || rule.CapPercentage > 100 {
+			return false, errors.New("Cap percentage must be between 0 and 100 in rule " + rule.RuleId)
+		}
+
+		if rule.RuleType == Rule_Tiered {
+			if len(rule.Tiers) == 0 {
+				return false, errors.New("Tiered rule " + rule.RuleId + " needs at least one tier")
+			}
+			ok, err := validateDiscountTiers(rule.Tiers)
+			if !ok {
+				return false, err
+			}
+		} else if rule.RuleType == Rule_PerDay || rule.RuleType == Rule_EarlyBonus {
+			if rule.PercentagePerDay <= 0 || rule.PercentagePerDay > 100 {
+				return false, errors.New("Percentage per day must be between 0 and 100 in rule " + rule.RuleId)
+			}
+		} else {
+			return false, errors.New("Invalid penalty rule type " + rule.RuleType)
+		}
+	}
+
+	return true, nil
+}
+
+// contractPenaltyRules falls back to the discount tiers of the contract's configuration version
+func contractPenaltyRules(stub shim.ChaincodeStubInterface, contractDetails contract) []penaltyRule {
+	if len(contractDetails.PenaltyRules) != 0 {
+		return contractDetails.PenaltyRules
+	}
+
+	contractConfig := getConfigurationByVersion(stub, contractDetails.ConfigVersion)
+	return []penaltyRule{{
+		RuleId:   "configuration",
+		RuleType: Rule_Tiered,
+		Tiers:    contractConfig.LateDeliveryDiscountTiers,
+	}}
+}
+
+// carriageBy is the party paying for the main carriage, the seller when cost only passes to the buyer on delivery
+func carriageBy(contractDetails contract) string {
+	if contractDetails.RiskTransfer != nil && contractDetails.RiskTransfer.CostTransferStatus != Shipment_Delivered {
+		return Party_Buyer
+	}
+	return Party_Seller
+}
+
+// sellerHandoverDate is when the seller's part of the delivery ended. When the buyer pays the carriage,
+// the carrier's delay up to the risk transfer is the buyer's and the seller is measured at Ready For Shipment.
+func sellerHandoverDate(contractDetails contract, riskTransferDate time.Time) time.Time {
+	if contractDetails.RiskTransfer == nil || carriageBy(contractDetails) == Party_Seller {
+		return riskTransferDate
+	}
+	readyDate, err := time.ParseInLocation("2006-01-02", contractDetails.ReadyForShipmentBySellerDate, riskTransferDate.Location())
+	if err != nil {
+		return riskTransferDate
+	}
+	return readyDate
+}
+
+func evaluatePenaltyRules(contractDetails contract, rules []penaltyRule, shipmentDate time.Time) ([]penaltyRecord, error) {
+	var penalties []penaltyRecord
+
+	deliveryDate, err := time.Parse(time.RFC3339, contractDetails.DeliveryDetails.DeliveryDate)
+	if err != nil {
+		return penalties, nil
+	}
+	daysLate := DiffDays(shipmentDate.Year(), int(shipmentDate.Month()), shipmentDate.Day(), deliveryDate.Year(), int(deliveryDate.Month()), deliveryDate.Day())
+
+	for _, rule := range rules {
+		var percentage float64
+
+		if rule.RuleType == Rule_Tiered {
+			days := daysLate - rule.GraceDays
+			for _, tier := range rule.Tiers {
+				if days >= tier.MinDays && (tier.MaxDays == 0 || days <= tier.MaxDays) {
+					percentage = tier.Percentage
+					break
+				}
+			}
+		} else if rule.RuleType == Rule_PerDay && daysLate-rule.GraceDays > 0 {
+			percentage = float64(daysLate-rule.GraceDays) * rule.PercentagePerDay
+		} else if rule.RuleType == Rule_EarlyBonus && -daysLate-rule.GraceDays > 0 {
+			percentage = float64(-daysLate-rule.GraceDays) * rule.PercentagePerDay
+		}
+
+		capApplied := false
+		if rule.CapPercentage > 0 && percentage > rule.CapPercentage {
+			percentage = rule.CapPercentage
+			capApplied = true
+		}
+		if percentage == 0 {
+			continue
+		}
+
+		//A bonus is a negative penalty, paid by the buyer
+		amount, err := contractDetails.TotalTradeAmount.Percentage(percentage)
+		if err != nil {
+			return nil, err
+		}
+		carriedBy := Party_Seller
+		if rule.RuleType == Rule_EarlyBonus {
+			percentage = -percentage
+			amount.Minor = -amount.Minor
+			carriedBy = Party_Buyer
+		}
+
+		penalties = append(penalties, penaltyRecord{
+			ContractId:   contractDetails.ContractId,
+			RuleId:       rule.RuleId,
+			RuleType:     rule.RuleType,
+			RuleSource:   contractDetails.PenaltyRuleSource,
+			DeliveryDate: contractDetails.DeliveryDetails.DeliveryDate,
+			ShipmentDate: shipmentDate.Format(dateFormat),
+			DaysLate:     daysLate,
+			GraceDays:    rule.GraceDays,
+			Percentage:   percentage,
+			CapApplied:   capApplied,
+			BaseAmount:   contractDetails.TotalTradeAmount,
+			Amount:       amount,
+			AppliedDate:  time.Now().Local().Format(dateFormat),
+			RiskStage:    riskTransferStatus(contractDetails),
+			CarriedBy:    carriedBy,
+		})
+	}
+
+	return penalties, nil
+}
+
+// applyPenalties sets the discount from every penalty recorded on the contract
+func applyPenalties(contractDetails contract, penalties []penaltyRecord) (contract, error) {
+	var percentage float64
+	total := money{Currency: contractDetails.TotalTradeAmount.Currency}
+
+	for _, element := range penalties {
+		percentage += element.Percentage
+		total, _ = total.Add(element.Amount)
+	}
+
+	//Penalties never take the amount below zero
+	if total.Minor > contractDetails.TotalTradeAmount.Minor {
+		total = contractDetails.TotalTradeAmount
+		percentage = 100
+	}
+
+	contractDetails.DiscountPercentage = percentage
+	contractDetails.DiscountedAmount, _ = contractDetails.TotalTradeAmount.Sub(total)
+	return applyContractTaxes(contractDetails)
+}
+
+func setOrganisationPenaltyRules(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var rules []penaltyRule
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	adminId := args[0]
+	organisationId := args[1]
+
+	if !hasUserRole(stub, adminId, Role_Admin) {
+		return nil, errors.New("Only admin can set penalty rules for " + organisationId)
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &rules)
+	if err != nil {
+		return nil, errors.New("Invalid penalty rules")
+	}
+
+	ok, err := validatePenaltyRules(rules)
+	if !ok {
+		return nil, err
+	}
+
+	//Contracts keep the rules they were created with
+	ruleSetList := getPenaltyRuleSetList(stub, organisationId)
+	ruleSetList = append(ruleSetList, penaltyRuleSet{
+		OrganisationId: organisationId,
+		Version:        len(ruleSetList) + 1,
+		Rules:          rules,
+		UpdatedDate:    time.Now().Local().Format(dateFormat),
+	})
+
+	ok = updatePenaltyRuleSetList(stub, organisationId, ruleSetList)
+	if !ok {
+		return nil, errors.New("Error in saving penalty rules")
+	}
+
+	return nil, nil
+}
+
+func getOrganisationPenaltyRules(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getPenaltyRuleSetList(stub, args[0]))
+	return jsonAsBytes, nil
+}
+
+func getPenaltyRecords(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read penalties")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getPenaltyList(stub, contractId))
+	return jsonAsBytes, nil
+}
+
+func getContractInvoiceList(stub shim.ChaincodeStubInterface, contractDetails contract, invoiceType string) []invoice {
+	var invoiceList []invoice
+	for _, element := range contractDetails.InvoiceNumbers {
+		invoiceDetails, found := getInvoiceDetails(stub, contractDetails.SellerDetails.Seller.UserId, element)
+		if found && (invoiceType == "" || invoiceDetails.InvoiceType == invoiceType) {
+			invoiceList = append(invoiceList, invoiceDetails)
+		}
+	}
+	return invoiceList
+}
+
+// invoicedQuantities returns the quantity per contract line still invoiced after credit notes
+func invoicedQuantities(invoiceList []invoice, originalInvoiceNumber string) map[int]*big.Rat {
+	quantities := map[int]*big.Rat{}
+	for _, element := range invoiceList {
+		if originalInvoiceNumber != "" && element.OriginalInvoiceNumber != originalInvoiceNumber {
+			continue
+		}
+		for _, line := range element.Lines {
+			quantity, _ := parseDecimal(line.Quantity)
+			if quantities[line.LineNumber] == nil {
+				quantities[line.LineNumber] = new(big.Rat)
+			}
+			if element.InvoiceType == Invoice_Type_CreditNote {
+				quantity.Neg(quantity)
+			}
+			quantities[line.LineNumber].Add(quantities[line.LineNumber], quantity)
+		}
+	}
+	return quantities
+}
+
+// deliveredQuantity caps what can be invoiced, contracts delivered before it was recorded use the contracted quantity
+func deliveredQuantity(line product) string {
+	if line.DeliveredQuantity == "" {
+		return line.ProductQuantity
+	}
+	return line.DeliveredQuantity
+}
+
+// applyDeliveredQuantities sets the delivered quantity of each line given, other lines keep theirs or default to the contracted quantity
+func applyDeliveredQuantities(tradeDetails []product, lines []invoiceLineRequest, invoiced map[int]*big.Rat) ([]product, error) {
+	tradeDetails = append([]product{}, tradeDetails...)
+	for _, element := range lines {
+		if element.LineNumber < 1 || element.LineNumber > len(tradeDetails) {
+			return nil, errors.New("Invalid line number " + strconv.Itoa(element.LineNumber))
+		}
+		quantity, err := parseDecimal(element.Quantity)
+		if err != nil || quantity.Sign() < 0 {
+			return nil, errors.New("Invalid delivered quantity on line " + strconv.Itoa(element.LineNumber))
+		}
+		contracted, _ := parseDecimal(tradeDetails[element.LineNumber-1].ProductQuantity)
+		if quantity.Cmp(contracted) > 0 {
+			return nil, errors.New("Delivered quantity on line " + strconv.Itoa(element.LineNumber) + " is above the contracted " + formatDecimal(contracted))
+		}
+		if billed := invoiced[element.LineNumber]; billed != nil && quantity.Cmp(billed) < 0 {
+			return nil, errors.New("Delivered quantity on line " + strconv.Itoa(element.LineNumber) + " is below the invoiced " + formatDecimal(billed))
+		}
+		tradeDetails[element.LineNumber-1].DeliveredQuantity = formatDecimal(quantity)
+	}
+
+	for i := range tradeDetails {
+		if tradeDetails[i].DeliveredQuantity == "" {
+			tradeDetails[i].DeliveredQuantity = tradeDetails[i].ProductQuantity
+		}
+	}
+	return tradeDetails, nil
+}
+
+func recordDeliveredQuantities(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var lines []invoiceLineRequest
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	buyerId := args[0]
+	contractId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.BuyerDetails.Buyer.UserId != buyerId {
+		return nil, errors.New("Only the buyer can record delivered quantities")
+	} else if contractDetails.ContractStatus != Shipment_Delivered {
+		return nil, errors.New("Delivered quantities can only be recorded while the contract is " + Shipment_Delivered)
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &lines)
+	if err != nil {
+		return nil, errors.New("Invalid delivered quantities")
+	}
+
+	//A line cannot drop below what is already invoiced
+	invoiced := invoicedQuantities(getContractInvoiceList(stub, contractDetails, ""), "")
+	tradeDetails, err := applyDeliveredQuantities(contractDetails.TradeDetails, lines, invoiced)
+	if err != nil {
+		return nil, err
+	}
+
+	contractDetails.TradeDetails = tradeDetails
+	contractDetails.LastUpdatedDate = time.Now().Local().Format(dateFormat)
+	ok := updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func nextInvoiceNumber(stub shim.ChaincodeStubInterface, sellerId string, invoiceType string) string {
+	prefix := "INV-"
+	if invoiceType == Invoice_Type_CreditNote {
+		prefix = "CN-"
+	}
+
+	//Invoices and credit notes run their own sequences
+	sequence := countSellerInvoices(stub, sellerId, prefix) + 1
+	for {
+		invoiceNumber := prefix + fmt.Sprintf("%06d", sequence)
+		if _, found := getInvoiceDetails(stub, sellerId, invoiceNumber); !found {
+			return invoiceNumber
+		}
+		sequence++
+	}
+}
+
+func buildInvoiceLine(contractDetails contract, lineNumber int, quantity *big.Rat, taxRate float64, dutyRate float64) (invoiceLine, error) {
+	currency := contractDetails.TradeConditions.Currency
+	product := contractDetails.TradeDetails[lineNumber-1]
+	price, _ := parseDecimal(product.ProductPrice)
+
+	var line invoiceLine
+	line.LineNumber = lineNumber
+	line.ProductName = product.ProductName
+	line.UnitPrice = product.ProductPrice
+	line.Quantity = formatDecimal(quantity)
+	var err error
+	line.GrossAmount, err = roundMoney(new(big.Rat).Mul(price, quantity), currency)
+	if err != nil {
+		return line, err
+	}
+	line.DiscountPercentage = contractDetails.DiscountPercentage
+	line.DiscountAmount, err = line.GrossAmount.Percentage(line.DiscountPercentage)
+	if err != nil {
+		return line, err
+	}
+	line.NetAmount, _ = line.GrossAmount.Sub(line.DiscountAmount)
+	line.TaxRate = taxRate
+	line.TaxAmount, err = line.NetAmount.Percentage(taxRate)
+	if err != nil {
+		return line, err
+	}
+	line.DutyRate = dutyRate
+	line.DutyAmount, err = line.NetAmount.Percentage(dutyRate)
+	if err != nil {
+		return line, err
+	}
+	line.TotalAmount, err = line.NetAmount.Add(line.TaxAmount)
+	return line, err
+}
+
+func createInvoice(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var request invoiceRequest
+	var invoiceDetails invoice
+	var originalInvoice invoice
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	sellerId := args[0]
+	contractId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.SellerDetails.Seller.UserId != sellerId {
+		return nil, errors.New("Only the seller can issue an invoice")
+	}
+	if contractDetails.ContractStatus != Shipment_Delivered && mapping_status(contractDetails.ContractStatus) != payment {
+		return nil, errors.New("Invoice can only be issued once the shipment is delivered")
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &request)
+	if err != nil {
+		return nil, errors.New("Invalid invoice")
+	}
+	if request.InvoiceType == "" {
+		request.InvoiceType = Invoice_Type_Invoice
+	}
+
+	invoiceList := getContractInvoiceList(stub, contractDetails, "")
+	remaining := map[int]*big.Rat{}
+
+	if request.InvoiceType == Invoice_Type_Invoice {
+		//Whatever is delivered and not yet invoiced on each contract line
+		invoiced := invoicedQuantities(invoiceList, "")
+		for i, product := range contractDetails.TradeDetails {
+			quantity, _ := parseDecimal(deliveredQuantity(product))
+			if invoiced[i+1] != nil {
+				quantity.Sub(quantity, invoiced[i+1])
+			}
+			remaining[i+1] = quantity
+		}
+		if len(request.Lines) == 0 {
+			for i := range contractDetails.TradeDetails {
+				if remaining[i+1].Sign() > 0 {
+					request.Lines = append(request.Lines, invoiceLineRequest{LineNumber: i + 1, Quantity: formatDecimal(remaining[i+1])})
+				}
+			}
+		}
+	} else if request.InvoiceType == Invoice_Type_CreditNote {
+		//Credit notes can only give back what the original invoice charged
+		found := false
+		originalInvoice, found = getInvoiceDetails(stub, sellerId, request.OriginalInvoiceNumber)
+		if !found || originalInvoice.ContractId != contractId || originalInvoice.InvoiceType != Invoice_Type_Invoice {
+			return nil, errors.New("Original invoice " + request.OriginalInvoiceNumber + " not found on contract")
+		}
+		_, offered := getReceivableOffer(stub, contractId, originalInvoice.InvoiceNumber)
+		if offered {
+			return nil, errors.New("Invoice " + originalInvoice.InvoiceNumber + " is offered for factoring and cannot be credited")
+		}
+		credited := invoicedQuantities(invoiceList, originalInvoice.InvoiceNumber)
+		for _, line := range originalInvoice.Lines {
+			quantity, _ := parseDecimal(line.Quantity)
+			if credited[line.LineNumber] != nil {
+				quantity.Add(quantity, credited[line.LineNumber])
+			}
+			remaining[line.LineNumber] = quantity
+		}
+	} else {
+		return nil, errors.New("Invalid invoice type " + request.InvoiceType)
+	}
+
+	if len(request.Lines) == 0 {
+		return nil, errors.New("Nothing left to invoice")
+	}
+
+	currency := contractDetails.TradeConditions.Currency
+	invoiceDetails.InvoiceType = request.InvoiceType
+	invoiceDetails.OriginalInvoiceNumber = originalInvoice.InvoiceNumber
+	invoiceDetails.ContractId = contractId
+	invoiceDetails.SellerId = sellerId
+	invoiceDetails.BuyerId = contractDetails.BuyerDetails.Buyer.UserId
+	invoiceDetails.Currency = currency
+	invoiceDetails.InvoiceDate = time.Now().Local().Format(dateFormat)
+	invoiceDetails.SubTotal = money{Currency: currency}
+	invoiceDetails.DiscountAmount = money{Currency: currency}
+	invoiceDetails.TaxAmount = money{Currency: currency}
+	invoiceDetails.DutyAmount = money{Currency: currency}
+	invoiceDetails.TotalAmount = money{Currency: currency}
+
+	for _, element := range request.Lines {
+		line := strconv.Itoa(element.LineNumber)
+		quantity, err := parseDecimal(element.Quantity)
+		if err != nil || quantity.Sign() <= 0 {
+			return nil, errors.New("Invalid quantity on invoice line " + line)
+		} else if remaining[element.LineNumber] == nil || quantity.Cmp(remaining[element.LineNumber]) > 0 {
+			return nil, errors.New("Quantity on invoice line " + line + " exceeds what can be invoiced")
+		} else if element.TaxRate < 0 || element.TaxRate > 100 {
+			return nil, errors.New("Tax rate on invoice line " + line + " must be between 0 and 100")
+		}
+		remaining[element.LineNumber].Sub(remaining[element.LineNumber], quantity)
+
+		//Rates come from the contract's tax details when it has them
+		taxRate := element.TaxRate
+		dutyRate := 0.0
+		if contractDetails.Taxes != nil {
+			lineTaxDetails := contractDetails.Taxes.Lines[element.LineNumber-1]
+			if element.TaxRate != 0 && element.TaxRate != lineTaxDetails.TaxRate {
+				return nil, errors.New("Tax rate on invoice line " + line + " is set by the contract")
+			}
+			taxRate = lineTaxDetails.TaxRate
+			dutyRate = lineTaxDetails.DutyRate
+		}
+		if request.InvoiceType == Invoice_Type_CreditNote {
+			for _, originalLine := range originalInvoice.Lines {
+				if originalLine.LineNumber == element.LineNumber {
+					taxRate = originalLine.TaxRate
+					dutyRate = originalLine.DutyRate
+				}
+			}
+		}
+
+		invoiceLineDetails, err := buildInvoiceLine(contractDetails, element.LineNumber, quantity, taxRate, dutyRate)
+		if err != nil {
+			return nil, err
+		}
+		invoiceDetails.Lines = append(invoiceDetails.Lines, invoiceLineDetails)
+		invoiceDetails.SubTotal, _ = invoiceDetails.SubTotal.Add(invoiceLineDetails.GrossAmount)
+		invoiceDetails.DiscountAmount, _ = invoiceDetails.DiscountAmount.Add(invoiceLineDetails.DiscountAmount)
+		invoiceDetails.TaxAmount, _ = invoiceDetails.TaxAmount.Add(invoiceLineDetails.TaxAmount)
+		invoiceDetails.DutyAmount, _ = invoiceDetails.DutyAmount.Add(invoiceLineDetails.DutyAmount)
+		invoiceDetails.TotalAmount, _ = invoiceDetails.TotalAmount.Add(invoiceLineDetails.TotalAmount)
+	}
+
+	//Due date follows the payment duration agreed on the contract
+	paymentDuration, err := strconv.Atoi(contractDetails.TradeConditions.PaymentDuration)
+	if err != nil || paymentDuration < 0 {
+		return nil, errors.New("Contract payment duration must be a number of days")
+	}
+	invoiceDate, _ := time.Parse(dateFormat, invoiceDetails.InvoiceDate)
+	invoiceDetails.DueDate = invoiceDate.AddDate(0, 0, paymentDuration).Format(dateFormat)
+
+	invoiceDetails.InvoiceNumber = request.InvoiceNumber
+	if invoiceDetails.InvoiceNumber == "" {
+		invoiceDetails.InvoiceNumber = nextInvoiceNumber(stub, sellerId, request.InvoiceType)
+	}
+	ok, err := insertInvoiceDetails(stub, invoiceDetails)
+	if !ok {
+		if err == nil {
+			err = errors.New("Invoice number " + invoiceDetails.InvoiceNumber + " already exists")
+		}
+		return nil, err
+	}
+
+	contractDetails.InvoiceNumbers = append(contractDetails.InvoiceNumbers, invoiceDetails.InvoiceNumber)
+	contractDetails.IsInvoiceListAttached = true
+	contractDetails.LastUpdatedDate = invoiceDetails.InvoiceDate
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return []byte(invoiceDetails.InvoiceNumber), nil
+}
+
+func getContractInvoices(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read invoices")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getContractInvoiceList(stub, contractDetails, ""))
+	return jsonAsBytes, nil
+}
+
+func addMoneyByCurrency(amountList []money, amount money) []money {
+	for i, element := range amountList {
+		if element.Currency == amount.Currency {
+			amountList[i], _ = element.Add(amount)
+			return amountList
+		}
+	}
+	return append(amountList, amount)
+}
+
+func validatePaymentSchedule(schedule []milestone) (bool, error) {
+	milestoneIds := map[string]bool{}
+	total := new(big.Rat)
+
+	for _, element := range schedule {
+		if element.MilestoneId == "" {
+			return false, errors.New("Milestone id is mandatory")
+		} else if milestoneIds[element.MilestoneId] {
+			return false, errors.New("Milestone id " + element.MilestoneId + " is repeated")
+		} else if element.TriggerStatus == Contract_Blocked || mapping_status(element.TriggerStatus) == "" {
+			return false, errors.New("Invalid trigger status " + element.TriggerStatus + " for milestone " + element.MilestoneId)
+		} else if element.Percentage <= 0 || element.Percentage > 100 {
+			return false, errors.New("Percentage of milestone " + element.MilestoneId + " must be between 0 and 100")
+		} else if element.DueDays < 0 {
+			return false, errors.New("Due days of milestone " + element.MilestoneId + " must not be negative")
+		}
+		milestoneIds[element.MilestoneId] = true
+
+		percentage, _ := new(big.Rat).SetString(strconv.FormatFloat(element.Percentage, 'f', -1, 64))
+		total.Add(total, percentage)
+	}
+
+	if total.Cmp(big.NewRat(100, 1)) != 0 {
+		return false, errors.New("Milestone percentages must add up to 100")
+	}
+	return true, nil
+}
+
+// triggerMilestones makes every milestone tied to the contract's current status due
+func triggerMilestones(stub shim.ChaincodeStubInterface, contractDetails contract, statusList []milestoneStatus) bool {
+	tradeAmount := contractTradeAmount(contractDetails)
+	today := time.Now().Local()
+	changed := false
+
+	statusById := map[string]milestoneStatus{}
+	for _, element := range statusList {
+		statusById[element.MilestoneId] = element
+	}
+
+	var updatedList []milestoneStatus
+	for i, element := range contractDetails.PaymentSchedule {
+		status, found := statusById[element.MilestoneId]
+		if !found {
+			status = milestoneStatus{MilestoneId: element.MilestoneId, Status: Milestone_Scheduled}
+			changed = true
+		}
+
+		if status.Status == Milestone_Scheduled && element.TriggerStatus == contractDetails.ContractStatus {
+			status.Status = Milestone_Due
+			status.TriggeredDate = today.Format(dateFormat)
+			status.DueDate = today.AddDate(0, 0, element.DueDays).Format(dateFormat)
+			status.AmountDue = milestoneAmount(contractDetails, tradeAmount, statusById, i)
+			status.AmountPaid = money{Currency: tradeAmount.Currency}
+			//Nothing to collect when rounding or penalties leave the milestone at zero
+			if status.AmountDue.IsZero() {
+				status.Status = Milestone_Paid
+			}
+			statusById[element.MilestoneId] = status
+			changed = true
+		}
+		updatedList = append(updatedList, status)
+	}
+
+	if !changed {
+		return true
+	}
+	return updateMilestoneStatusList(stub, contractDetails.ContractId, updatedList)
+}
+
+// milestoneAmount gives the last milestone to fall due whatever is left of the trade amount
+func milestoneAmount(contractDetails contract, tradeAmount money, statusById map[string]milestoneStatus, index int) money {
+	alreadyDue := money{Currency: tradeAmount.Currency}
+	isLast := true
+	for i, element := range contractDetails.PaymentSchedule {
+		if i == index {
+			continue
+		}
+		status := statusById[element.MilestoneId]
+		if status.Status == "" || status.Status == Milestone_Scheduled {
+			isLast = false
+			break
+		}
+		alreadyDue, _ = alreadyDue.Add(status.AmountDue)
+	}
+
+	if isLast {
+		remaining, _ := tradeAmount.Sub(alreadyDue)
+		return remaining
+	}
+	//Milestone percentages are at most 100, so this never exceeds the trade amount
+	amount, _ := tradeAmount.Percentage(contractDetails.PaymentSchedule[index].Percentage)
+	return amount
+}
+
+func recordMilestonePayment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	milestoneId := args[2]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.BuyerDetails.Buyer.UserId != userId && contractDetails.BuyerDetails.BuyerBank.UserId != userId {
+		return nil, errors.New("Only the buyer or the buyer's bank can record a milestone payment")
+	}
+
+	amount, err := parseMoney(args[3], contractDetails.TradeConditions.Currency)
+	if err != nil || amount.Minor <= 0 {
+		return nil, errors.New("Payment amount must be a positive " + contractDetails.TradeConditions.Currency + " amount")
+	}
+
+	statusList := getMilestoneStatusList(stub, contractId)
+	found := false
+	for i, element := range statusList {
+		if element.MilestoneId != milestoneId {
+			continue
+		}
+		found = true
+
+		if element.Status != Milestone_Due && element.Status != Milestone_PartiallyPaid {
+			return nil, errors.New("Milestone " + milestoneId + " is " + element.Status)
+		}
+		outstanding, _ := element.AmountDue.Sub(element.AmountPaid)
+		if amount.Minor > outstanding.Minor {
+			return nil, errors.New("Payment exceeds the outstanding " + outstanding.String() + " on milestone " + milestoneId)
+		}
+
+		element.AmountPaid, _ = element.AmountPaid.Add(amount)
+		element.Status = Milestone_PartiallyPaid
+		if element.AmountPaid == element.AmountDue {
+			element.Status = Milestone_Paid
+		}
+		statusList[i] = element
+	}
+	if !found {
+		return nil, errors.New("Milestone " + milestoneId + " not found")
+	}
+
+	ok := updateMilestoneStatusList(stub, contractId, statusList)
+	if !ok {
+		return nil, errors.New("Error in updating payment schedule")
+	}
+
+	return nil, nil
+}
+
+func getPaymentSchedule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read the payment schedule")
+	}
+
+	jsonAsBytes, _ := json.Marshal(getMilestoneStatusList(stub, contractId))
+	return jsonAsBytes, nil
+}
+
+// parsePaymentDetails builds the payment record for the payment transition the contract is moving to
+func parsePaymentDetails(stub shim.ChaincodeStubInterface, contractDetails contract, userId string, paymentJSON string) (paymentRecord, error) {
+	var request paymentRequest
+	err := json.Unmarshal([]byte(paymentJSON), &request)
+	if err != nil {
+		return paymentRecord{}, errors.New("Invalid payment details")
+	}
+
+	//Receivables sold to a financier are paid to the financier, so those payments name the invoice
+	payeeId := contractDetails.SellerDetails.Seller.UserId
+	var invoiceDetails invoice
+	if request.InvoiceNumber != "" {
+		found := false
+		invoiceDetails, found = getInvoiceDetails(stub, contractDetails.SellerDetails.Seller.UserId, request.InvoiceNumber)
+		if !found || invoiceDetails.ContractId != contractDetails.ContractId || invoiceDetails.InvoiceType != Invoice_Type_Invoice {
+			return paymentRecord{}, errors.New("Invoice " + request.InvoiceNumber + " not found on contract")
+		}
+		offer, offered := getReceivableOffer(stub, contractDetails.ContractId, request.InvoiceNumber)
+		if offered && offer.Status == Receivable_Assigned {
+			payeeId = offer.AcceptedFinancierId
+		}
+	} else if contractDetails.ContractStatus != Payment_Completed_to_Seller_Bank {
+		for _, element := range getContractReceivableOffers(stub, contractDetails.ContractId) {
+			if element.Status == Receivable_Assigned {
+				return paymentRecord{}, errors.New("Invoice number is required, invoice " + element.InvoiceNumber + " is assigned to a financier")
+			}
+		}
+	}
+	if contractDetails.ContractStatus == Payment_Completed_to_Seller_Bank {
+		payeeId = contractDetails.SellerDetails.SellerBank.UserId
+	}
+
+	//Without an amount the net invoiced amount is paid
+	currency := contractDetails.TradeConditions.Currency
+	if request.Amount.Currency == "" && request.Amount.Minor == 0 {
+		if request.InvoiceNumber != "" {
+			request.Amount = invoiceFaceAmount(getContractInvoiceList(stub, contractDetails, Invoice_Type_CreditNote), invoiceDetails)
+		} else {
+			request.Amount = netInvoicedAmount(getContractInvoiceList(stub, contractDetails, ""), currency)
+		}
+	}
+	if request.Amount.Currency != currency || request.Amount.Minor <= 0 {
+		return paymentRecord{}, errors.New("Payment amount must be a positive " + currency + " amount")
+	}
+	if strings.TrimSpace(request.BankReference) == "" {
+		return paymentRecord{}, errors.New("Bank reference is mandatory")
+	}
+	_, err = time.Parse(dateFormat, request.ValueDate)
+	if err != nil {
+		return paymentRecord{}, errors.New("Value date must be in " + dateFormat + " format")
+	}
+	for _, element := range getPaymentList(stub, contractDetails.ContractId) {
+		if element.BankReference == request.BankReference {
+			return paymentRecord{}, errors.New("Bank reference " + request.BankReference + " is already recorded")
+		}
+	}
+
+	return paymentRecord{
+		ContractId:    contractDetails.ContractId,
+		InvoiceNumber: request.InvoiceNumber,
+		PaymentType:   contractDetails.ContractStatus,
+		PayerId:       userId,
+		PayeeId:       payeeId,
+		Amount:        request.Amount,
+		BankReference: request.BankReference,
+		ValueDate:     request.ValueDate,
+		RecordedDate:  time.Now().Local().Format(dateFormat),
+	}, nil
+}
+
+func netInvoicedAmount(invoiceList []invoice, currency string) money {
+	invoiced := money{Currency: currency}
+	for _, element := range invoiceList {
+		if element.InvoiceType == Invoice_Type_CreditNote {
+			invoiced, _ = invoiced.Sub(element.TotalAmount)
+		} else {
+			invoiced, _ = invoiced.Add(element.TotalAmount)
+		}
+	}
+	return invoiced
+}
+
+// reconcilePayments compares each payment leg against the net invoiced amount
+func reconcilePayments(invoiceList []invoice, paymentList []paymentRecord, currency string) []paymentReconciliation {
+	invoiced := netInvoicedAmount(invoiceList, currency)
+
+	var reconciliationList []paymentReconciliation
+	for _, paymentType := range []string{Payment_Completed_to_Seller, Payment_Completed_to_Seller_Bank} {
+		reconciliation := paymentReconciliation{
+			PaymentType:    paymentType,
+			InvoicedAmount: invoiced,
+			PaidAmount:     money{Currency: currency},
+			Payments:       []paymentRecord{},
+		}
+		for _, element := range paymentList {
+			if element.PaymentType == paymentType {
+				reconciliation.PaidAmount, _ = reconciliation.PaidAmount.Add(element.Amount)
+				reconciliation.Payments = append(reconciliation.Payments, element)
+			}
+		}
+		reconciliation.Difference, _ = reconciliation.PaidAmount.Sub(invoiced)
+
+		if len(reconciliation.Payments) == 0 && !invoiced.IsZero() {
+			reconciliation.Status = Reconciliation_Missing
+		} else if reconciliation.Difference.Minor < 0 {
+			reconciliation.Status = Reconciliation_Underpaid
+		} else if reconciliation.Difference.Minor > 0 {
+			reconciliation.Status = Reconciliation_Overpaid
+		} else {
+			reconciliation.Status = Reconciliation_Matched
+		}
+		reconciliationList = append(reconciliationList, reconciliation)
+	}
+	return reconciliationList
+}
+
+func getContractPayments(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read payments")
+	}
+
+	paymentList := getPaymentList(stub, contractId)
+	if paymentList == nil {
+		paymentList = []paymentRecord{}
+	}
+	jsonAsBytes, _ := json.Marshal(paymentList)
+	return jsonAsBytes, nil
+}
+
+func getPaymentReconciliation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can reconcile payments")
+	}
+
+	reconciliationList := reconcilePayments(getContractInvoiceList(stub, contractDetails, ""), getPaymentList(stub, contractId), contractDetails.TradeConditions.Currency)
+	jsonAsBytes, _ := json.Marshal(reconciliationList)
+	return jsonAsBytes, nil
+}
+
+// invoiceFaceAmount is what is left of the invoice after its credit notes
+func invoiceFaceAmount(creditNoteList []invoice, invoiceDetails invoice) money {
+	faceAmount := invoiceDetails.TotalAmount
+	for _, element := range creditNoteList {
+		if element.OriginalInvoiceNumber == invoiceDetails.InvoiceNumber {
+			faceAmount, _ = faceAmount.Sub(element.TotalAmount)
+		}
+	}
+	return faceAmount
+}
+
+func offerReceivable(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	sellerId := args[0]
+	contractId := args[1]
+	invoiceNumber := args[2]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.SellerDetails.Seller.UserId != sellerId {
+		return nil, errors.New("Only the seller can offer a receivable")
+	}
+	if contractDetails.ContractStatus != Invoice_Created {
+		return nil, errors.New("Receivable can only be offered while the contract is " + Invoice_Created)
+	}
+	_, offered := getReceivableOffer(stub, contractId, invoiceNumber)
+	if offered {
+		return nil, errors.New("Invoice " + invoiceNumber + " is already offered")
+	}
+
+	invoiceDetails, found := getInvoiceDetails(stub, sellerId, invoiceNumber)
+	if !found || invoiceDetails.ContractId != contractId || invoiceDetails.InvoiceType != Invoice_Type_Invoice {
+		return nil, errors.New("Invoice " + invoiceNumber + " not found on contract")
+	}
+
+	faceAmount := invoiceFaceAmount(getContractInvoiceList(stub, contractDetails, Invoice_Type_CreditNote), invoiceDetails)
+	if faceAmount.Minor <= 0 {
+		return nil, errors.New("Invoice " + invoiceNumber + " has nothing left to finance")
+	}
+
+	offer := receivableOffer{
+		ContractId:    contractId,
+		InvoiceNumber: invoiceNumber,
+		SellerId:      sellerId,
+		FaceAmount:    faceAmount,
+		DueDate:       invoiceDetails.DueDate,
+		OfferDate:     time.Now().Local().Format(dateFormat),
+		Status:        Receivable_Open,
+		Bids:          []receivableBid{},
+	}
+	ok := updateReceivableOffer(stub, offer)
+	if !ok {
+		return nil, errors.New("Error in recording receivable offer")
+	}
+
+	ok = updateOpenReceivableList(stub, append(getOpenReceivableList(stub), receivableKey{ContractId: contractId, InvoiceNumber: invoiceNumber}))
+	if !ok {
+		return nil, errors.New("Error in updating open receivables")
+	}
+
+	return nil, nil
+}
+
+func bidReceivable(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	financierId := args[0]
+	contractId := args[1]
+	invoiceNumber := args[2]
+
+	if !hasUserRole(stub, financierId, Role_Financier) {
+		return nil, errors.New("Only registered financiers can bid on receivables")
+	}
+
+	offer, found := getReceivableOffer(stub, contractId, invoiceNumber)
+	if !found || offer.Status != Receivable_Open {
+		return nil, errors.New("No open receivable for invoice " + invoiceNumber + " on contract " + contractId)
+	}
+	if offer.SellerId == financierId {
+		return nil, errors.New("Seller cannot bid on its own receivable")
+	}
+
+	discountRate, err := strconv.ParseFloat(args[3], 64)
+	if err != nil || discountRate <= 0 || discountRate >= 100 {
+		return nil, errors.New("Discount rate must be between 0 and 100")
+	}
+
+	discount, err := offer.FaceAmount.Percentage(discountRate)
+	if err != nil {
+		return nil, err
+	}
+	advanceAmount, _ := offer.FaceAmount.Sub(discount)
+	bid := receivableBid{
+		FinancierId:   financierId,
+		DiscountRate:  discountRate,
+		AdvanceAmount: advanceAmount,
+		BidDate:       time.Now().Local().Format(dateFormat),
+	}
+
+	//A new bid replaces the financier's earlier one
+	var bidList []receivableBid
+	for _, element := range offer.Bids {
+		if element.FinancierId != financierId {
+			bidList = append(bidList, element)
+		}
+	}
+	offer.Bids = append(bidList, bid)
+
+	ok := updateReceivableOffer(stub, offer)
+	if !ok {
+		return nil, errors.New("Error in recording bid")
+	}
+
+	return nil, nil
+}
+
+func acceptReceivableBid(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	sellerId := args[0]
+	contractId := args[1]
+	invoiceNumber := args[2]
+	financierId := args[3]
+
+	offer, found := getReceivableOffer(stub, contractId, invoiceNumber)
+	if !found || offer.SellerId != sellerId {
+		return nil, errors.New("No receivable offered by " + sellerId + " for invoice " + invoiceNumber)
+	}
+	if offer.Status != Receivable_Open {
+		return nil, errors.New("Receivable for invoice " + invoiceNumber + " is " + offer.Status)
+	}
+
+	bidFound := false
+	for _, element := range offer.Bids {
+		if element.FinancierId == financierId {
+			bidFound = true
+		}
+	}
+	if !bidFound {
+		return nil, errors.New("No bid from " + financierId + " for invoice " + invoiceNumber)
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.ContractStatus != Invoice_Created {
+		return nil, errors.New("Receivable can only be assigned while the contract is " + Invoice_Created)
+	}
+
+	//The invoice must not already be financed elsewhere
+	check, ok := registerDocumentFinancing(stub, financierId, Document_Invoice, documentHash(getRegistrySalt(stub), Document_Invoice, sellerId, offer.InvoiceNumber))
+	if !ok {
+		return nil, errors.New("Error in updating financing registry")
+	}
+	//The attempt is kept on the ledger, the offer stays open for another bid
+	if check.Status == Financing_Duplicate {
+		jsonAsBytes, _ := json.Marshal(check)
+		return jsonAsBytes, nil
+	}
+
+	offer.Status = Receivable_Assigned
+	offer.AcceptedFinancierId = financierId
+	offer.AssignmentDate = time.Now().Local().Format(dateFormat)
+	ok = updateReceivableOffer(stub, offer)
+	if !ok {
+		return nil, errors.New("Error in recording assignment")
+	}
+
+	var openList []receivableKey
+	for _, element := range getOpenReceivableList(stub) {
+		if element.ContractId != contractId || element.InvoiceNumber != invoiceNumber {
+			openList = append(openList, element)
+		}
+	}
+	ok = updateOpenReceivableList(stub, openList)
+	if !ok {
+		return nil, errors.New("Error in updating open receivables")
+	}
+
+	return nil, nil
+}
+
+// getReceivableOfferForUser shows the seller every bid and a financier only its own
+func getReceivableOfferForUser(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	contractId := args[0]
+	invoiceNumber := args[1]
+	userId := args[2]
+
+	offer, found := getReceivableOffer(stub, contractId, invoiceNumber)
+	if !found {
+		return nil, errors.New("No receivable offered for invoice " + invoiceNumber + " on contract " + contractId)
+	}
+
+	if offer.SellerId != userId {
+		if !hasUserRole(stub, userId, Role_Financier) {
+			return nil, errors.New("Only the seller and registered financiers can read receivable offers")
+		}
+		bidList := []receivableBid{}
+		for _, element := range offer.Bids {
+			if element.FinancierId == userId {
+				bidList = append(bidList, element)
+			}
+		}
+		offer.Bids = bidList
+	}
+
+	jsonAsBytes, _ := json.Marshal(offer)
+	return jsonAsBytes, nil
+}
+
+func getOpenReceivables(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 arguments")
+	}
+
+	financierId := args[0]
+	if !hasUserRole(stub, financierId, Role_Financier) {
+		return nil, errors.New("Only registered financiers can list open receivables")
+	}
+
+	offerList := []receivableOffer{}
+	for _, element := range getOpenReceivableList(stub) {
+		offer, found := getReceivableOffer(stub, element.ContractId, element.InvoiceNumber)
+		if !found {
+			continue
+		}
+		//Competing bids stay hidden
+		bidList := []receivableBid{}
+		for _, bid := range offer.Bids {
+			if bid.FinancierId == financierId {
+				bidList = append(bidList, bid)
+			}
+		}
+		offer.Bids = bidList
+		offerList = append(offerList, offer)
+	}
+
+	jsonAsBytes, _ := json.Marshal(offerList)
+	return jsonAsBytes, nil
+}
+
+// documentHash fingerprints a trade document so banks can compare financings without sharing the document
+// documentHash is salted per registry so the hash of a guessable invoice number cannot be computed off ledger
+func documentHash(salt string, documentType string, issuerId string, documentNumber string) string {
+	normalise := func(value string) string {
+		return strings.ToUpper(strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, value))
+	}
+	hash := sha256.Sum256([]byte(salt + "|" + documentType + "|" + normalise(issuerId) + "|" + normalise(documentNumber)))
+	return hex.EncodeToString(hash[:])
+}
+
+func isDocumentType(documentType string) bool {
+	return documentType == Document_Invoice || documentType == Document_BillOfLading
+}
+
+// registerDocumentFinancing records the financing, or the attempt when another financier already holds the document
+func registerDocumentFinancing(stub shim.ChaincodeStubInterface, financierId string, documentType string, hash string) (financingCheck, bool) {
+	today := time.Now().Local().Format(dateFormat)
+	check := financingCheck{DocumentHash: hash, DocumentType: documentType, Status: Financing_Active, Owned: true}
+
+	registration, found := getFinancingRegistration(stub, hash)
+	if found && registration.Status == Financing_Active {
+		if registration.FinancierId == financierId {
+			return check, true
+		}
+		check.Status = Financing_Duplicate
+		check.Owned = false
+		registration.DuplicateAttempts = append(registration.DuplicateAttempts, financingAttempt{FinancierId: financierId, AttemptDate: today})
+		return check, updateFinancingRegistration(stub, registration)
+	}
+
+	registration = financingRegistration{
+		DocumentHash:      hash,
+		DocumentType:      documentType,
+		FinancierId:       financierId,
+		Status:            Financing_Active,
+		RegisteredDate:    today,
+		DuplicateAttempts: []financingAttempt{},
+	}
+	return check, updateFinancingRegistration(stub, registration)
+}
+
+func registerFinancing(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	financierId := args[0]
+	documentType := args[1]
+	hash := strings.ToLower(args[2])
+
+	if !hasUserRole(stub, financierId, Role_Financier) {
+		return nil, errors.New("Only registered financiers can register a financing")
+	}
+	if !isDocumentType(documentType) {
+		return nil, errors.New("Invalid document type " + documentType)
+	}
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil || len(hashBytes) != sha256.Size {
+		return nil, errors.New("Document hash must be a hex encoded SHA-256")
+	}
+
+	//A duplicate is recorded against the first financing rather than rejected, so the attempt stays on the ledger
+	check, ok := registerDocumentFinancing(stub, financierId, documentType, hash)
+	if !ok {
+		return nil, errors.New("Error in updating financing registry")
+	}
+
+	jsonAsBytes, _ := json.Marshal(check)
+	return jsonAsBytes, nil
+}
+
+func releaseFinancing(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	financierId := args[0]
+	hash := strings.ToLower(args[1])
+
+	registration, found := getFinancingRegistration(stub, hash)
+	if !found || registration.Status != Financing_Active || registration.FinancierId != financierId {
+		return nil, errors.New("No active financing by " + financierId + " for this document")
+	}
+
+	registration.Status = Financing_Released
+	registration.ReleasedDate = time.Now().Local().Format(dateFormat)
+	ok := updateFinancingRegistration(stub, registration)
+	if !ok {
+		return nil, errors.New("Error in updating financing registry")
+	}
+
+	return nil, nil
+}
+
+func getDocumentHash(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	if !hasUserRole(stub, args[0], Role_Financier) {
+		return nil, errors.New("Only registered financiers can compute document hashes")
+	}
+	if !isDocumentType(args[1]) {
+		return nil, errors.New("Invalid document type " + args[1])
+	}
+
+	return []byte(documentHash(getRegistrySalt(stub), args[1], args[2], args[3])), nil
+}
+
+// getFinancingStatus tells a financier whether a document is financed without naming the other financier
+func getFinancingStatus(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	financierId := args[0]
+	hash := strings.ToLower(args[1])
+
+	if !hasUserRole(stub, financierId, Role_Financier) {
+		return nil, errors.New("Only registered financiers can check the financing registry")
+	}
+
+	registration, found := getFinancingRegistration(stub, hash)
+	if found && registration.FinancierId == financierId {
+		jsonAsBytes, _ := json.Marshal(registration)
+		return jsonAsBytes, nil
+	}
+
+	check := financingCheck{DocumentHash: hash, Status: Financing_Free}
+	if found && registration.Status == Financing_Active {
+		check.DocumentType = registration.DocumentType
+		check.Status = Financing_Active
+	}
+
+	jsonAsBytes, _ := json.Marshal(check)
+	return jsonAsBytes, nil
+}
+
+// creditExposureAmount is the most the LC can be drawn for, tolerance included
+func creditExposureAmount(lcDetails letterOfCredit) money {
+	//validateLetterOfCreditTerms has already checked the amount plus tolerance fits
+	tolerance, _ := lcDetails.Amount.Percentage(lcDetails.TolerancePercentage)
+	exposure, _ := lcDetails.Amount.Add(tolerance)
+	return exposure
+}
+
+func creditUtilised(exposureList []creditExposure, clientId string, currency string, excludeLCNumber string) money {
+	utilised := money{Currency: currency}
+	for _, element := range exposureList {
+		if element.Status == Exposure_Open && element.ClientId == clientId && element.Amount.Currency == currency && element.LCNumber != excludeLCNumber {
+			utilised, _ = utilised.Add(element.Amount)
+		}
+	}
+	return utilised
+}
+
+func hasOpenCreditExposure(stub shim.ChaincodeStubInterface, bankId string, lcNumber string) bool {
+	for _, element := range getCreditExposureList(stub, bankId) {
+		if element.LCNumber == lcNumber && element.Status == Exposure_Open {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCreditLimit applies only to banks that manage limits, a client without a limit there has nothing available
+func checkCreditLimit(stub shim.ChaincodeStubInterface, bankId string, clientId string, exposure money, lcNumber string) error {
+	limitList := getCreditLimitList(stub, bankId)
+	if len(limitList) == 0 {
+		return nil
+	}
+
+	limit := money{Currency: exposure.Currency}
+	for _, element := range limitList {
+		if element.ClientId == clientId && element.Limit.Currency == exposure.Currency {
+			limit = element.Limit
+		}
+	}
+
+	utilised := creditUtilised(getCreditExposureList(stub, bankId), clientId, exposure.Currency, lcNumber)
+	available, _ := limit.Sub(utilised)
+	if exposure.Minor > available.Minor {
+		return errors.New("LC exposure " + exposure.String() + " exceeds available credit limit " + available.String() + " for " + clientId)
+	}
+	return nil
+}
+
+func openCreditExposure(stub shim.ChaincodeStubInterface, lcDetails letterOfCredit) bool {
+	exposure := creditExposure{
+		LCNumber:   lcDetails.LCNumber,
+		ContractId: lcDetails.ContractId,
+		ClientId:   lcDetails.Applicant,
+		Amount:     creditExposureAmount(lcDetails),
+		Status:     Exposure_Open,
+		OpenedDate: time.Now().Local().Format(dateFormat),
+	}
+
+	var exposureList []creditExposure
+	for _, element := range getCreditExposureList(stub, lcDetails.IssuingBank) {
+		if element.LCNumber == lcDetails.LCNumber && element.Status == Exposure_Open {
+			exposure.OpenedDate = element.OpenedDate
+			continue
+		}
+		exposureList = append(exposureList, element)
+	}
+	return updateCreditExposureList(stub, lcDetails.IssuingBank, append(exposureList, exposure))
+}
+
+func closeCreditExposure(stub shim.ChaincodeStubInterface, bankId string, lcNumber string, status string) bool {
+	exposureList := getCreditExposureList(stub, bankId)
+	changed := false
+	for i, element := range exposureList {
+		if element.LCNumber == lcNumber && element.Status == Exposure_Open {
+			exposureList[i].Status = status
+			exposureList[i].ClosedDate = time.Now().Local().Format(dateFormat)
+			changed = true
+		}
+	}
+	if !changed {
+		return true
+	}
+	return updateCreditExposureList(stub, bankId, exposureList)
+}
+
+func setCreditLimit(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	bankId := args[0]
+	clientId := args[1]
+	currency := args[2]
+
+	if bankId == clientId {
+		return nil, errors.New("Bank cannot set a credit limit for itself")
+	}
+	if !isSupportedCurrency(currency) {
+		return nil, errors.New("Unsupported currency " + currency)
+	}
+	limit, err := parseMoney(args[3], currency)
+	if err != nil || limit.Minor < 0 {
+		return nil, errors.New("Credit limit must be a non negative " + currency + " amount")
+	}
+
+	var limitList []creditLimit
+	for _, element := range getCreditLimitList(stub, bankId) {
+		if element.ClientId != clientId || element.Limit.Currency != currency {
+			limitList = append(limitList, element)
+		}
+	}
+	limitList = append(limitList, creditLimit{ClientId: clientId, Limit: limit, UpdatedDate: time.Now().Local().Format(dateFormat)})
+
+	ok := updateCreditLimitList(stub, bankId, limitList)
+	if !ok {
+		return nil, errors.New("Error in updating credit limits")
+	}
+
+	return nil, nil
+}
+
+func getCreditUtilisation(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	bankId := args[0]
+	exposureList := getCreditExposureList(stub, bankId)
+	utilisationList := []creditUtilisation{}
+	index := map[string]int{}
+
+	for _, element := range getCreditLimitList(stub, bankId) {
+		limit := element.Limit
+		index[element.ClientId+"/"+limit.Currency] = len(utilisationList)
+		utilisationList = append(utilisationList, creditUtilisation{ClientId: element.ClientId, Currency: limit.Currency, Limit: &limit})
+	}
+	//Clients with open exposure but no limit
+	for _, element := range exposureList {
+		key := element.ClientId + "/" + element.Amount.Currency
+		if _, found := index[key]; !found && element.Status == Exposure_Open {
+			index[key] = len(utilisationList)
+			utilisationList = append(utilisationList, creditUtilisation{ClientId: element.ClientId, Currency: element.Amount.Currency})
+		}
+	}
+
+	for i, element := range utilisationList {
+		element.Utilised = creditUtilised(exposureList, element.ClientId, element.Currency, "")
+		element.Exposures = []creditExposure{}
+		for _, exposure := range exposureList {
+			if exposure.Status == Exposure_Open && exposure.ClientId == element.ClientId && exposure.Amount.Currency == element.Currency {
+				element.Exposures = append(element.Exposures, exposure)
+			}
+		}
+		if element.Limit != nil {
+			available, _ := element.Limit.Sub(element.Utilised)
+			element.Available = &available
+		}
+		utilisationList[i] = element
+	}
+
+	jsonAsBytes, _ := json.Marshal(utilisationList)
+	return jsonAsBytes, nil
+}
+
+func isCountryCode(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+	for _, r := range country {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func normaliseHSCode(hsCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, hsCode)
+}
+
+// findDutyRate uses the longest HS code prefix in the table, an empty HS code is the country default
+func findDutyRate(rateTable taxRateTable, hsCode string) (float64, bool) {
+	hsCode = normaliseHSCode(hsCode)
+	found := false
+	rate := 0.0
+	length := -1
+	for _, element := range rateTable.DutyRates {
+		prefix := normaliseHSCode(element.HSCode)
+		if strings.HasPrefix(hsCode, prefix) && len(prefix) > length {
+			rate = element.Rate
+			length = len(prefix)
+			found = true
+		}
+	}
+	return rate, found
+}
+
+// lookupContractTaxes takes each line's tax and duty rate from the destination country's rate table
+func lookupContractTaxes(stub shim.ChaincodeStubInterface, contractDetails contract) (*contractTaxes, error) {
+	country := contractDetails.DeliveryDetails.DestinationCountry
+	if country == "" {
+		for _, element := range contractDetails.TradeDetails {
+			if element.HSCode != "" || element.TaxCategory != "" {
+				return nil, errors.New("Destination country is required for HS code and tax category")
+			}
+		}
+		return nil, nil
+	}
+	if !isCountryCode(country) {
+		return nil, errors.New("Destination country must be an ISO 3166 two letter code")
+	}
+
+	rateTable, found := getTaxRateTable(stub, country)
+	if !found {
+		return nil, errors.New("No tax rates for destination country " + country)
+	}
+
+	taxes := contractTaxes{Country: country}
+	for i, element := range contractDetails.TradeDetails {
+		line := strconv.Itoa(i + 1)
+		lineTaxDetails := lineTax{LineNumber: i + 1, HSCode: element.HSCode, TaxCategory: element.TaxCategory}
+
+		if element.TaxCategory != "" {
+			categoryFound := false
+			for _, rate := range rateTable.TaxRates {
+				if rate.TaxCategory == element.TaxCategory {
+					lineTaxDetails.TaxRate = rate.Rate
+					categoryFound = true
+				}
+			}
+			if !categoryFound {
+				return nil, errors.New("No " + country + " tax rate for category " + element.TaxCategory + " on line " + line)
+			}
+		}
+		if element.HSCode != "" {
+			if len(normaliseHSCode(element.HSCode)) < 6 {
+				return nil, errors.New("HS code on line " + line + " must have at least 6 digits")
+			}
+			rate, dutyFound := findDutyRate(rateTable, element.HSCode)
+			if !dutyFound {
+				return nil, errors.New("No " + country + " duty rate for HS code " + element.HSCode + " on line " + line)
+			}
+			lineTaxDetails.DutyRate = rate
+		}
+		taxes.Lines = append(taxes.Lines, lineTaxDetails)
+	}
+	return &taxes, nil
+}
+
+// applyContractTaxes works out tax and duty on each line net of the contract discount
+func applyContractTaxes(contractDetails contract) (contract, error) {
+	if contractDetails.Taxes == nil {
+		return contractDetails, nil
+	}
+
+	currency := contractDetails.TradeConditions.Currency
+	taxes := *contractDetails.Taxes
+	taxes.Lines = append([]lineTax{}, taxes.Lines...)
+	taxes.TaxAmount = money{Currency: currency}
+	taxes.DutyAmount = money{Currency: currency}
+
+	for i, element := range taxes.Lines {
+		product := contractDetails.TradeDetails[element.LineNumber-1]
+		gross, _ := parseMoney(product.TotalAmount, currency)
+		discount, err := gross.Percentage(contractDetails.DiscountPercentage)
+		if err != nil {
+			return contractDetails, err
+		}
+		net, _ := gross.Sub(discount)
+
+		element.TaxAmount, err = net.Percentage(element.TaxRate)
+		if err != nil {
+			return contractDetails, err
+		}
+		element.DutyAmount, err = net.Percentage(element.DutyRate)
+		if err != nil {
+			return contractDetails, err
+		}
+		taxes.TaxAmount, err = taxes.TaxAmount.Add(element.TaxAmount)
+		if err != nil {
+			return contractDetails, err
+		}
+		taxes.DutyAmount, err = taxes.DutyAmount.Add(element.DutyAmount)
+		if err != nil {
+			return contractDetails, err
+		}
+		taxes.Lines[i] = element
+	}
+
+	contractDetails.Taxes = &taxes
+	return contractDetails, nil
+}
+
+func setTaxRates(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var rateTable taxRateTable
+
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	adminId := args[0]
+	if !hasUserRole(stub, adminId, Role_Admin) {
+		return nil, errors.New("Only admin can maintain tax rates")
+	}
+
+	err := json.Unmarshal([]byte(args[1]), &rateTable)
+	if err != nil {
+		return nil, errors.New("Invalid tax rate table")
+	}
+	if !isCountryCode(rateTable.Country) {
+		return nil, errors.New("Country must be an ISO 3166 two letter code")
+	}
+
+	categories := map[string]bool{}
+	for _, element := range rateTable.TaxRates {
+		if element.TaxCategory == "" || categories[element.TaxCategory] {
+			return nil, errors.New("Tax categories must be named and unique")
+		} else if element.Rate < 0 || element.Rate > 100 {
+			return nil, errors.New("Tax rate for " + element.TaxCategory + " must be between 0 and 100")
+		}
+		categories[element.TaxCategory] = true
+	}
+	hsCodes := map[string]bool{}
+	for _, element := range rateTable.DutyRates {
+		hsCode := normaliseHSCode(element.HSCode)
+		if (element.HSCode != "" && hsCode == "") || hsCodes[hsCode] {
+			return nil, errors.New("HS codes in duty rates must be numeric and unique")
+		} else if element.Rate < 0 || element.Rate > 100 {
+			return nil, errors.New("Duty rate for HS code " + element.HSCode + " must be between 0 and 100")
+		}
+		hsCodes[hsCode] = true
+	}
+
+	rateTable.UpdatedBy = adminId
+	rateTable.UpdatedDate = time.Now().Local().Format(dateFormat)
+	ok := updateTaxRateTable(stub, rateTable)
+	if !ok {
+		return nil, errors.New("Error in updating tax rates")
+	}
+
+	return nil, nil
+}
+
+func getTaxRates(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	rateTable, found := getTaxRateTable(stub, args[0])
+	if !found {
+		return nil, errors.New("No tax rates for country " + args[0])
+	}
+
+	jsonAsBytes, _ := json.Marshal(rateTable)
+	return jsonAsBytes, nil
+}
+
+func settlementBalance(balanceList []money, currency string) money {
+	for _, element := range balanceList {
+		if element.Currency == currency {
+			return element
+		}
+	}
+	return money{Currency: currency}
+}
+
+func checkSettlementFunds(stub shim.ChaincodeStubInterface, ownerId string, amount money) error {
+	balance := settlementBalance(getSettlementBalanceList(stub, ownerId), amount.Currency)
+	if balance.Minor < amount.Minor {
+		return errors.New("Insufficient settlement funds: " + ownerId + " holds " + balance.String() + " " + amount.Currency + ", " + amount.String() + " needed")
+	}
+	return nil
+}
+
+// adjustSettlementBalance credits a positive amount and debits a negative one, never below zero
+func adjustSettlementBalance(stub shim.ChaincodeStubInterface, ownerId string, amount money) error {
+	balanceList := getSettlementBalanceList(stub, ownerId)
+	balance, err := settlementBalance(balanceList, amount.Currency).Add(amount)
+	if err != nil {
+		return err
+	}
+	if balance.Minor < 0 {
+		return errors.New("Insufficient settlement funds for " + ownerId)
+	}
+
+	var updatedList []money
+	for _, element := range balanceList {
+		if element.Currency != amount.Currency {
+			updatedList = append(updatedList, element)
+		}
+	}
+	updatedList = append(updatedList, balance)
+
+	ok := updateSettlementBalanceList(stub, ownerId, updatedList)
+	if !ok {
+		return errors.New("Error in updating settlement balance")
+	}
+	return nil
+}
+
+func transferSettlementFunds(stub shim.ChaincodeStubInterface, fromId string, toId string, amount money) error {
+	debit := money{Minor: -amount.Minor, Currency: amount.Currency}
+	err := adjustSettlementBalance(stub, fromId, debit)
+	if err != nil {
+		return err
+	}
+	return adjustSettlementBalance(stub, toId, amount)
+}
+
+func parseSettlementArgs(stub shim.ChaincodeStubInterface, args []string) (string, money, error) {
+	if len(args) != 4 {
+		return "", money{}, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	agentId := args[0]
+	ownerId := args[1]
+	currency := args[3]
+
+	if !hasUserRole(stub, agentId, Role_SettlementAgent) {
+		return "", money{}, errors.New("Only the settlement agent can move funds on or off the ledger")
+	}
+	if !isSupportedCurrency(currency) {
+		return "", money{}, errors.New("Unsupported currency " + currency)
+	}
+	amount, err := parseMoney(args[2], currency)
+	if err != nil || amount.Minor <= 0 {
+		return "", money{}, errors.New("Amount must be a positive " + currency + " amount")
+	}
+	return ownerId, amount, nil
+}
+
+// depositSettlementFunds issues tokens against funds the settlement agent holds off the ledger
+func depositSettlementFunds(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	ownerId, amount, err := parseSettlementArgs(stub, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = adjustSettlementBalance(stub, ownerId, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func withdrawSettlementFunds(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	ownerId, amount, err := parseSettlementArgs(stub, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkSettlementFunds(stub, ownerId, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = adjustSettlementBalance(stub, ownerId, money{Minor: -amount.Minor, Currency: amount.Currency})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func getSettlementBalances(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	balanceList := getSettlementBalanceList(stub, args[0])
+	if balanceList == nil {
+		balanceList = []money{}
+	}
+
+	jsonAsBytes, _ := json.Marshal(balanceList)
+	return jsonAsBytes, nil
+}
+
+func validateLateInterestTerms(terms lateInterestTerms) (bool, error) {
+	if terms.AnnualRate < 0 || terms.AnnualRate > 100 {
+		return false, errors.New("Late payment interest rate must be between 0 and 100 percent")
+	}
+	if terms.DayCount != DayCount_Act360 && terms.DayCount != DayCount_Act365 && terms.DayCount != DayCount_30E360 {
+		return false, errors.New("Day count must be " + DayCount_Act360 + ", " + DayCount_Act365 + " or " + DayCount_30E360)
+	}
+	return true, nil
+}
+
+// dayCountFraction returns the days counted between two dates and the year basis for the convention
+func dayCountFraction(dayCount string, from time.Time, to time.Time) (int, int) {
+	if dayCount == DayCount_30E360 {
+		d1 := from.Day()
+		if d1 > 30 {
+			d1 = 30
+		}
+		d2 := to.Day()
+		if d2 > 30 {
+			d2 = 30
+		}
+		return 360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + (d2 - d1), 360
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	if dayCount == DayCount_Act365 {
+		return days, 365
+	}
+	return days, 360
+}
+
+// calculateLateInterest accrues simple interest on each invoice from its own due date, stepping down the unpaid amount at each payment's value date. Invoices issued after asOf are ignored
+func calculateLateInterest(contractDetails contract, invoiceList []invoice, paymentList []paymentRecord, asOf time.Time) (interestAccrual, error) {
+	currency := contractDetails.TradeConditions.Currency
+	accrual := interestAccrual{
+		ContractId:      contractDetails.ContractId,
+		PaymentDueDate:  contractDetails.PaymentDueDate,
+		AsOfDate:        asOf.Format(dateFormat),
+		Principal:       money{Currency: currency},
+		Periods:         []interestPeriod{},
+		AccruedInterest: money{Currency: currency},
+	}
+	if contractDetails.LateInterest == nil {
+		return accrual, nil
+	}
+	accrual.AnnualRate = contractDetails.LateInterest.AnnualRate
+	accrual.DayCount = contractDetails.LateInterest.DayCount
+
+	//Amounts become overdue on each invoice's due date and stop being overdue as they are paid or credited
+	type balanceChange struct {
+		date   time.Time
+		amount money
+	}
+	var changes []balanceChange
+	dueDates := map[string]time.Time{}
+	for _, element := range invoiceList {
+		invoiceDate, err := time.Parse(dateFormat, element.InvoiceDate)
+		if err != nil || invoiceDate.After(asOf) || element.InvoiceType == Invoice_Type_CreditNote {
+			continue
+		}
+		dueDate, err := time.Parse(dateFormat, element.DueDate)
+		if err != nil {
+			dueDate = invoiceDate
+		}
+		dueDates[element.InvoiceNumber] = dueDate
+		accrual.Principal, _ = accrual.Principal.Add(element.TotalAmount)
+		changes = append(changes, balanceChange{dueDate, element.TotalAmount})
+	}
+	for _, element := range invoiceList {
+		invoiceDate, err := time.Parse(dateFormat, element.InvoiceDate)
+		if err != nil || invoiceDate.After(asOf) || element.InvoiceType != Invoice_Type_CreditNote {
+			continue
+		}
+		//A credit note cannot reduce an amount before it is due
+		creditDate := invoiceDate
+		if dueDate, found := dueDates[element.OriginalInvoiceNumber]; found && dueDate.After(creditDate) {
+			creditDate = dueDate
+		}
+		accrual.Principal, _ = accrual.Principal.Sub(element.TotalAmount)
+		changes = append(changes, balanceChange{creditDate, money{Minor: -element.TotalAmount.Minor, Currency: currency}})
+	}
+	for _, element := range paymentList {
+		valueDate, err := time.Parse(dateFormat, element.ValueDate)
+		if err != nil || valueDate.After(asOf) || element.PaymentType != Payment_Completed_to_Seller_Bank {
+			continue
+		}
+		changes = append(changes, balanceChange{valueDate, money{Minor: -element.Amount.Minor, Currency: currency}})
+	}
+	if len(changes) == 0 {
+		return accrual, nil
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].date.Before(changes[j].date) })
+
+	rate, _ := new(big.Rat).SetString(strconv.FormatFloat(accrual.AnnualRate, 'f', -1, 64))
+	rate.Quo(rate, big.NewRat(100, 1))
+
+	outstanding := money{Currency: currency}
+	cursor := changes[0].date
+	addPeriod := func(to time.Time) error {
+		if !to.After(cursor) {
+			return nil
+		}
+		if outstanding.Minor > 0 {
+			days, basis := dayCountFraction(accrual.DayCount, cursor, to)
+			interest, err := outstanding.Mul(new(big.Rat).Mul(rate, big.NewRat(int64(days), int64(basis))))
+			if err != nil {
+				return err
+			}
+			accrual.Periods = append(accrual.Periods, interestPeriod{
+				FromDate:    cursor.Format(dateFormat),
+				ToDate:      to.Format(dateFormat),
+				Days:        days,
+				Outstanding: outstanding,
+				Interest:    interest,
+			})
+			accrual.AccruedInterest, err = accrual.AccruedInterest.Add(interest)
+			if err != nil {
+				return err
+			}
+		}
+		cursor = to
+		return nil
+	}
+
+	for _, element := range changes {
+		if element.date.After(asOf) {
+			break
+		}
+		err := addPeriod(element.date)
+		if err != nil {
+			return accrual, err
+		}
+		outstanding, _ = outstanding.Add(element.amount)
+	}
+	err := addPeriod(asOf)
+	return accrual, err
+}
+
+func getAccruedInterest(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+	asOf, err := time.Parse(dateFormat, args[2])
+	if err != nil {
+		return nil, errors.New("As of date must be in " + dateFormat + " format")
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read accrued interest")
+	}
+
+	accrual, err := calculateLateInterest(contractDetails, getContractInvoiceList(stub, contractDetails, ""), getPaymentList(stub, contractId), asOf)
+	if err != nil {
+		return nil, err
+	}
+	jsonAsBytes, _ := json.Marshal(accrual)
+	return jsonAsBytes, nil
+}
+
+func validateFeeRules(fees []feeRule) (bool, error) {
+	events := map[string]bool{}
+	for _, element := range fees {
+		if element.Event != Fee_LCIssuance && element.Event != Fee_LCAdvising && element.Event != Fee_LCAmendment && element.Event != Fee_Payment {
+			return false, errors.New("Invalid fee event " + element.Event)
+		} else if !isSupportedCurrency(element.Currency) {
+			return false, errors.New("Unsupported currency " + element.Currency)
+		} else if events[element.Event+"/"+element.Currency] {
+			return false, errors.New("Fee for " + element.Event + " in " + element.Currency + " is repeated")
+		} else if element.Percentage < 0 || element.Percentage > 100 {
+			return false, errors.New("Fee percentage must be between 0 and 100")
+		}
+		for _, amount := range []money{element.MinimumAmount, element.FixedAmount} {
+			if amount.Minor < 0 || (amount.Minor != 0 && amount.Currency != element.Currency) {
+				return false, errors.New("Fee amounts for " + element.Event + " must be non negative " + element.Currency + " amounts")
+			}
+		}
+		events[element.Event+"/"+element.Currency] = true
+	}
+	return true, nil
+}
+
+// calculateFee is the percentage of the base amount, at least the minimum, plus the fixed amount
+func calculateFee(rule feeRule, base money) (money, error) {
+	fee, err := base.Percentage(rule.Percentage)
+	if err != nil {
+		return fee, err
+	}
+	if fee.Minor < rule.MinimumAmount.Minor {
+		fee.Minor = rule.MinimumAmount.Minor
+	}
+	fixed := money{Minor: rule.FixedAmount.Minor, Currency: fee.Currency}
+	return fee.Add(fixed)
+}
+
+// feePayer allocates a bank's fee to the buyer or seller under the contract's charges clause
+func feePayer(contractDetails contract, bankId string) (string, string) {
+	if contractDetails.ChargesClause == Charges_Our {
+		return Party_Buyer, contractDetails.BuyerDetails.Buyer.UserId
+	} else if contractDetails.ChargesClause == Charges_Ben {
+		return Party_Seller, contractDetails.SellerDetails.Seller.UserId
+	}
+	if bankId == contractDetails.BuyerDetails.BuyerBank.UserId {
+		return Party_Buyer, contractDetails.BuyerDetails.Buyer.UserId
+	}
+	return Party_Seller, contractDetails.SellerDetails.Seller.UserId
+}
+
+// contractFeeVersion is the bank's fee schedule version pinned when the contract was saved
+func contractFeeVersion(contractDetails contract, bankId string) int {
+	if contractDetails.SellerDetails.SellerBank.UserId == bankId {
+		return contractDetails.SellerBankFeeVersion
+	} else if contractDetails.BuyerDetails.BuyerBank.UserId == bankId {
+		return contractDetails.BuyerBankFeeVersion
+	}
+	return 0
+}
+
+func chargeBankFee(stub shim.ChaincodeStubInterface, contractDetails contract, bankId string, event string, base money, reference string) bool {
+	schedule, found := getFeeScheduleVersion(stub, bankId, contractFeeVersion(contractDetails, bankId))
+	if !found {
+		return true
+	}
+
+	for _, element := range schedule.Fees {
+		if element.Event != event || element.Currency != base.Currency {
+			continue
+		}
+		fee, err := calculateFee(element, base)
+		if err != nil {
+			return false
+		}
+		if fee.IsZero() {
+			return true
+		}
+
+		chargedTo, payerId := feePayer(contractDetails, bankId)
+		charge := feeCharge{
+			ContractId:  contractDetails.ContractId,
+			BankId:      bankId,
+			Event:       event,
+			Reference:   reference,
+			BaseAmount:  base,
+			Amount:      fee,
+			ChargedTo:   chargedTo,
+			PayerId:     payerId,
+			ChargedDate: time.Now().Local().Format(dateFormat),
+		}
+		return updateFeeList(stub, contractDetails.ContractId, append(getFeeList(stub, contractDetails.ContractId), charge))
+	}
+	return true
+}
+
+func setFeeSchedule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var schedule feeSchedule
+
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	bankId := args[0]
+	if !hasUserRole(stub, bankId, Role_Bank) {
+		return nil, errors.New("Only banks can set a fee schedule")
+	}
+
+	err := json.Unmarshal([]byte(args[1]), &schedule.Fees)
+	if err != nil {
+		return nil, errors.New("Invalid fee schedule")
+	}
+	ok, err := validateFeeRules(schedule.Fees)
+	if !ok {
+		return nil, err
+	}
+
+	//Contracts keep the version in force when they were saved
+	schedule.BankId = bankId
+	schedule.Version = 1
+	if current, found := getFeeSchedule(stub, bankId); found {
+		schedule.Version = current.Version + 1
+	}
+	schedule.UpdatedDate = time.Now().Local().Format(dateFormat)
+	ok = updateFeeSchedule(stub, schedule)
+	if !ok {
+		return nil, errors.New("Error in updating fee schedule")
+	}
+
+	return nil, nil
+}
+
+func getBankFeeSchedule(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("Incorrect number of arguments. Need 1 argument")
+	}
+
+	schedule, found := getFeeSchedule(stub, args[0])
+	if !found {
+		return nil, errors.New("No fee schedule for " + args[0])
+	}
+
+	jsonAsBytes, _ := json.Marshal(schedule)
+	return jsonAsBytes, nil
+}
+
+// getFeeStatement lists the fees a party pays, or for a bank the fees it charged
+func getFeeStatement(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read fee statements")
+	}
+
+	statement := feeStatement{ContractId: contractId, UserId: userId, Charges: []feeCharge{}, Total: []money{}}
+	for _, element := range getFeeList(stub, contractId) {
+		if element.PayerId == userId || element.BankId == userId {
+			statement.Charges = append(statement.Charges, element)
+			statement.Total = addMoneyByCurrency(statement.Total, element.Amount)
+		}
+	}
+
+	jsonAsBytes, _ := json.Marshal(statement)
+	return jsonAsBytes, nil
+}
+
+// guaranteeParties returns the bank, applicant and beneficiary for a guarantee type on the contract
+func guaranteeParties(contractDetails contract, guaranteeType string) (string, string, string, bool) {
+	if guaranteeType == Guarantee_Performance || guaranteeType == Guarantee_AdvancePayment {
+		return contractDetails.SellerDetails.SellerBank.UserId, contractDetails.SellerDetails.Seller.UserId, contractDetails.BuyerDetails.Buyer.UserId, true
+	} else if guaranteeType == Guarantee_Standby {
+		return contractDetails.BuyerDetails.BuyerBank.UserId, contractDetails.BuyerDetails.Buyer.UserId, contractDetails.SellerDetails.Seller.UserId, true
+	}
+	return "", "", "", false
+}
+
+func validateGuaranteeTerms(guaranteeDetails guarantee) error {
+	if guaranteeDetails.Amount.Minor <= 0 || !isSupportedCurrency(guaranteeDetails.Amount.Currency) {
+		return errors.New("Guarantee amount must be a positive amount in a supported currency")
+	}
+	if guaranteeDetails.ClaimedAmount.Minor > guaranteeDetails.Amount.Minor {
+		return errors.New("Guarantee amount must not be below the amount already claimed")
+	}
+	_, err := time.Parse(dateFormat, guaranteeDetails.ValidFrom)
+	if err != nil {
+		return errors.New("Guarantee valid from date must be in " + dateFormat + " format")
+	}
+	_, err = time.Parse(dateFormat, guaranteeDetails.ExpiryDate)
+	if err != nil {
+		return errors.New("Guarantee expiry date must be in " + dateFormat + " format")
+	}
+	if guaranteeDetails.ExpiryDate < time.Now().Local().Format(dateFormat) {
+		return errors.New("Guarantee expiry date must not be in the past")
+	} else if guaranteeDetails.ExpiryDate < guaranteeDetails.ValidFrom {
+		return errors.New("Guarantee expiry date must not be before its valid from date")
+	}
+	return nil
+}
+
+// expireGuaranteeIfDue marks a live guarantee expired once its expiry date has passed
+func expireGuaranteeIfDue(guaranteeDetails guarantee) (guarantee, bool) {
+	if guaranteeDetails.Status == Guarantee_Status_Issued && guaranteeDetails.ExpiryDate < time.Now().Local().Format(dateFormat) {
+		guaranteeDetails.Status = Guarantee_Status_Expired
+		return guaranteeDetails, true
+	}
+	return guaranteeDetails, false
+}
+
+// checkStandbyCover lets a live standby guarantee for the trade amount stand in for the letter of credit
+func checkStandbyCover(stub shim.ChaincodeStubInterface, contractDetails contract) error {
+	tradeAmount := contractTradeAmount(contractDetails)
+	for _, guaranteeNumber := range contractDetails.GuaranteeNumbers {
+		guaranteeDetails, err := liveGuarantee(stub, guaranteeNumber)
+		if err != nil || guaranteeDetails.GuaranteeType != Guarantee_Standby || guaranteeDetails.ValidFrom > time.Now().Local().Format(dateFormat) {
+			continue
+		}
+		available, err := guaranteeDetails.Amount.Sub(guaranteeDetails.ClaimedAmount)
+		if err == nil && available.Currency == tradeAmount.Currency && available.Minor >= tradeAmount.Minor {
+			return nil
+		}
+	}
+	return errors.New("A live standby guarantee for " + tradeAmount.String() + " " + tradeAmount.Currency + " is required for " + contractDetails.ContractStatus)
+}
+
+func issueGuarantee(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var guaranteeDetails guarantee
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	bankId := args[0]
+	contractId := args[1]
+
+	err := json.Unmarshal([]byte(args[2]), &guaranteeDetails)
+	if err != nil {
+		return nil, errors.New("Invalid guarantee")
+	}
+	if guaranteeDetails.GuaranteeNumber == "" {
+		return nil, errors.New("Guarantee number is mandatory")
+	}
+	if _, found := getGuaranteeDetails(stub, guaranteeDetails.GuaranteeNumber); found {
+		return nil, errors.New("Guarantee number " + guaranteeDetails.GuaranteeNumber + " already exists")
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	issuingBank, applicant, beneficiary, valid := guaranteeParties(contractDetails, guaranteeDetails.GuaranteeType)
+	if !valid {
+		return nil, errors.New("Guarantee type must be " + Guarantee_Performance + ", " + Guarantee_AdvancePayment + " or " + Guarantee_Standby)
+	} else if issuingBank != bankId {
+		return nil, errors.New("Only the applicant's bank can issue a " + guaranteeDetails.GuaranteeType + " guarantee")
+	} else if contractDetails.ContractStatus == Contract_Completed || contractDetails.ContractStatus == Contract_Blocked {
+		return nil, errors.New("Guarantee cannot be issued on a " + contractDetails.ContractStatus + " contract")
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	guaranteeDetails.ContractId = contractId
+	guaranteeDetails.IssuingBank = issuingBank
+	guaranteeDetails.Applicant = applicant
+	guaranteeDetails.Beneficiary = beneficiary
+	guaranteeDetails.ClaimedAmount = money{Currency: guaranteeDetails.Amount.Currency}
+	guaranteeDetails.Status = Guarantee_Status_Issued
+	guaranteeDetails.IssueDate = today
+	guaranteeDetails.ReleaseDate = ""
+	guaranteeDetails.Amendments = []guaranteeAmendment{}
+	guaranteeDetails.Claims = []guaranteeClaim{}
+	if guaranteeDetails.ValidFrom == "" {
+		guaranteeDetails.ValidFrom = today
+	}
+
+	err = validateGuaranteeTerms(guaranteeDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := updateGuaranteeDetails(stub, guaranteeDetails)
+	if !ok {
+		return nil, errors.New("Error in saving guarantee")
+	}
+
+	contractDetails.GuaranteeNumbers = append(contractDetails.GuaranteeNumbers, guaranteeDetails.GuaranteeNumber)
+	contractDetails.LastUpdatedDate = today
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func applyGuaranteeAmendment(guaranteeDetails guarantee, amendment guaranteeAmendment) guarantee {
+	if amendment.Amount.Currency != "" {
+		guaranteeDetails.Amount = amendment.Amount
+	}
+	if amendment.ExpiryDate != "" {
+		guaranteeDetails.ExpiryDate = amendment.ExpiryDate
+	}
+	return guaranteeDetails
+}
+
+// liveGuarantee loads a guarantee that can still be amended, claimed or released
+func liveGuarantee(stub shim.ChaincodeStubInterface, guaranteeNumber string) (guarantee, error) {
+	guaranteeDetails, found := getGuaranteeDetails(stub, guaranteeNumber)
+	if !found {
+		return guaranteeDetails, errors.New("Guarantee " + guaranteeNumber + " not found")
+	}
+	guaranteeDetails, _ = expireGuaranteeIfDue(guaranteeDetails)
+	if guaranteeDetails.Status != Guarantee_Status_Issued {
+		return guaranteeDetails, errors.New("Guarantee " + guaranteeNumber + " is " + guaranteeDetails.Status)
+	}
+	return guaranteeDetails, nil
+}
+
+func amendGuarantee(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var amendment guaranteeAmendment
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	bankId := args[0]
+	guaranteeNumber := args[1]
+
+	guaranteeDetails, err := liveGuarantee(stub, guaranteeNumber)
+	if err != nil {
+		return nil, err
+	} else if guaranteeDetails.IssuingBank != bankId {
+		return nil, errors.New("Only the issuing bank can amend the guarantee")
+	}
+	for _, element := range guaranteeDetails.Amendments {
+		if element.Status == Amendment_Pending {
+			return nil, errors.New("Guarantee already has a pending amendment")
+		}
+	}
+
+	err = json.Unmarshal([]byte(args[2]), &amendment)
+	if err != nil {
+		return nil, errors.New("Invalid guarantee amendment")
+	}
+	if amendment.Amount.Currency != "" && amendment.Amount.Currency != guaranteeDetails.Amount.Currency {
+		return nil, errors.New("Guarantee amendment must keep the currency " + guaranteeDetails.Amount.Currency)
+	}
+	err = validateGuaranteeTerms(applyGuaranteeAmendment(guaranteeDetails, amendment))
+	if err != nil {
+		return nil, err
+	}
+
+	amendment.AmendmentNumber = len(guaranteeDetails.Amendments) + 1
+	amendment.Status = Amendment_Pending
+	amendment.RequestDate = time.Now().Local().Format(dateFormat)
+	amendment.ResponseDate = ""
+	guaranteeDetails.Amendments = append(guaranteeDetails.Amendments, amendment)
+
+	ok := updateGuaranteeDetails(stub, guaranteeDetails)
+	if !ok {
+		return nil, errors.New("Error in updating guarantee")
+	}
+
+	return nil, nil
+}
+
+// respondGuaranteeAmendment lets the beneficiary accept or reject, an amendment does not bind it otherwise
+func respondGuaranteeAmendment(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	guaranteeNumber := args[1]
+	amendmentNumber, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, errors.New("Amendment number must be a number")
+	}
+	decision := args[3]
+
+	if decision != Amendment_Accepted && decision != Amendment_Rejected {
+		return nil, errors.New("Decision must be " + Amendment_Accepted + " or " + Amendment_Rejected)
+	}
+
+	guaranteeDetails, err := liveGuarantee(stub, guaranteeNumber)
+	if err != nil {
+		return nil, err
+	} else if guaranteeDetails.Beneficiary != userId {
+		return nil, errors.New("Only the beneficiary can respond to a guarantee amendment")
+	} else if amendmentNumber < 1 || amendmentNumber > len(guaranteeDetails.Amendments) {
+		return nil, errors.New("Amendment " + args[2] + " not found")
+	}
+
+	amendment := guaranteeDetails.Amendments[amendmentNumber-1]
+	if amendment.Status != Amendment_Pending {
+		return nil, errors.New("Amendment " + args[2] + " is already " + amendment.Status)
+	}
+
+	if decision == Amendment_Accepted {
+		amendedGuarantee := applyGuaranteeAmendment(guaranteeDetails, amendment)
+		err = validateGuaranteeTerms(amendedGuarantee)
+		if err != nil {
+			return nil, err
+		}
+		guaranteeDetails = amendedGuarantee
+	}
+
+	amendment.Status = decision
+	amendment.ResponseDate = time.Now().Local().Format(dateFormat)
+	guaranteeDetails.Amendments[amendmentNumber-1] = amendment
+
+	ok := updateGuaranteeDetails(stub, guaranteeDetails)
+	if !ok {
+		return nil, errors.New("Error in updating guarantee")
+	}
+
+	return nil, nil
+}
+
+// claimGuarantee pays the beneficiary from the issuing bank's settlement balance
+func claimGuarantee(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	guaranteeNumber := args[1]
+	statement := args[3]
+
+	guaranteeDetails, err := liveGuarantee(stub, guaranteeNumber)
+	if err != nil {
+		return nil, err
+	} else if guaranteeDetails.Beneficiary != userId {
+		return nil, errors.New("Only the beneficiary can claim under the guarantee")
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	if today < guaranteeDetails.ValidFrom {
+		return nil, errors.New("Guarantee is not valid before " + guaranteeDetails.ValidFrom)
+	}
+	if strings.TrimSpace(statement) == "" {
+		return nil, errors.New("Claim statement is mandatory")
+	}
+
+	currency := guaranteeDetails.Amount.Currency
+	amount, err := parseMoney(args[2], currency)
+	if err != nil || amount.Minor <= 0 {
+		return nil, errors.New("Claim amount must be a positive " + currency + " amount")
+	}
+	available, _ := guaranteeDetails.Amount.Sub(guaranteeDetails.ClaimedAmount)
+	if amount.Minor > available.Minor {
+		return nil, errors.New("Claim exceeds the available guarantee amount " + available.String())
+	}
+
+	err = checkSettlementFunds(stub, guaranteeDetails.IssuingBank, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = transferSettlementFunds(stub, guaranteeDetails.IssuingBank, userId, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	guaranteeDetails.ClaimedAmount, _ = guaranteeDetails.ClaimedAmount.Add(amount)
+	guaranteeDetails.Claims = append(guaranteeDetails.Claims, guaranteeClaim{
+		ClaimNumber: len(guaranteeDetails.Claims) + 1,
+		Amount:      amount,
+		Statement:   statement,
+		ClaimDate:   today,
+	})
+	if guaranteeDetails.ClaimedAmount == guaranteeDetails.Amount {
+		guaranteeDetails.Status = Guarantee_Status_Exhausted
+	}
+
+	ok := updateGuaranteeDetails(stub, guaranteeDetails)
+	if !ok {
+		return nil, errors.New("Error in updating guarantee")
+	}
+
+	return nil, nil
+}
+
+// releaseGuarantee frees the bank from the guarantee, by the beneficiary at any time or by the bank once expired
+func releaseGuarantee(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	userId := args[0]
+	guaranteeNumber := args[1]
+
+	guaranteeDetails, found := getGuaranteeDetails(stub, guaranteeNumber)
+	if !found {
+		return nil, errors.New("Guarantee " + guaranteeNumber + " not found")
+	}
+	guaranteeDetails, _ = expireGuaranteeIfDue(guaranteeDetails)
+
+	if guaranteeDetails.Status == Guarantee_Status_Issued {
+		if guaranteeDetails.Beneficiary != userId {
+			return nil, errors.New("Only the beneficiary can release a guarantee before it expires")
+		}
+		guaranteeDetails.Status = Guarantee_Status_Released
+	} else if guaranteeDetails.Status != Guarantee_Status_Expired || (userId != guaranteeDetails.IssuingBank && userId != guaranteeDetails.Beneficiary) {
+		return nil, errors.New("Guarantee " + guaranteeNumber + " is " + guaranteeDetails.Status)
+	}
+	guaranteeDetails.ReleaseDate = time.Now().Local().Format(dateFormat)
+
+	ok := updateGuaranteeDetails(stub, guaranteeDetails)
+	if !ok {
+		return nil, errors.New("Error in updating guarantee")
+	}
+
+	return nil, nil
+}
+
+func getGuarantee(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	guaranteeNumber := args[0]
+	userId := args[1]
+
+	guaranteeDetails, found := getGuaranteeDetails(stub, guaranteeNumber)
+	if !found {
+		return nil, errors.New("Guarantee " + guaranteeNumber + " not found")
+	}
+	if userId != guaranteeDetails.IssuingBank && userId != guaranteeDetails.Applicant && userId != guaranteeDetails.Beneficiary {
+		return nil, errors.New("Only the guarantee parties can read the guarantee")
+	}
+
+	guaranteeDetails, _ = expireGuaranteeIfDue(guaranteeDetails)
+	jsonAsBytes, _ := json.Marshal(guaranteeDetails)
+	return jsonAsBytes, nil
+}
+
+func getContractGuarantees(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if !isPricingParty(contractDetails, userId) {
+		return nil, errors.New("Only buyer, seller and their banks can read contract guarantees")
+	}
+
+	guaranteeList := []guarantee{}
+	for _, element := range contractDetails.GuaranteeNumbers {
+		guaranteeDetails, found := getGuaranteeDetails(stub, element)
+		if found {
+			guaranteeDetails, _ = expireGuaranteeIfDue(guaranteeDetails)
+			guaranteeList = append(guaranteeList, guaranteeDetails)
+		}
+	}
+
+	jsonAsBytes, _ := json.Marshal(guaranteeList)
+	return jsonAsBytes, nil
+}
+
+func postTrackingEvent(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var event trackingEvent
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if currentCarrier(contractDetails) != userId {
+		return nil, errors.New("Only the transporter carrying the goods can post tracking events")
+	} else if mapping_status(contractDetails.ContractStatus) != shipment {
+		return nil, errors.New("Tracking events can only be posted during shipment")
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &event)
+	if err != nil {
+		return nil, errors.New("Invalid tracking event")
+	}
+	if !Tracking_Event_Types[event.EventType] {
+		return nil, errors.New("Invalid tracking event type " + event.EventType)
+	} else if strings.TrimSpace(event.Location) == "" {
+		return nil, errors.New("Tracking event location is mandatory")
+	}
+	eventTime, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		return nil, errors.New("Tracking event timestamp must be in RFC 3339 format")
+	} else if eventTime.After(time.Now()) {
+		return nil, errors.New("Tracking event timestamp must not be in the future")
+	}
+
+	eventList := getTrackingEventList(stub, contractId)
+	event.EventNumber = len(eventList) + 1
+	event.ContractId = contractId
+	event.PostedBy = userId
+	event.PostedDate = time.Now().Local().Format(dateFormat)
+
+	ok := updateTrackingEventList(stub, contractId, append(eventList, event))
+	if !ok {
+		return nil, errors.New("Error in recording tracking event")
+	}
+
+	//Events can arrive out of order, the latest timestamp gives the current location
+	latest, _ := time.Parse(time.RFC3339, contractDetails.LocationUpdatedAt)
+	if contractDetails.LocationUpdatedAt == "" || eventTime.After(latest) {
+		contractDetails.CurrentLocation = event.Location
+		contractDetails.LocationUpdatedAt = event.Timestamp
+		contractDetails.LastUpdatedDate = event.PostedDate
+		ok = updateContractListByContractID(stub, contractId, contractDetails)
+		if !ok {
+			return nil, errors.New("Error in updating contract list")
+		}
+	}
+
+	return nil, nil
+}
+
+func getShipmentTimeline(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if len(getContractRoles(contractDetails, userId)) == 0 {
+		return nil, errors.New("Only contract parties can read the shipment timeline")
+	}
+
+	eventList := getTrackingEventList(stub, contractId)
+	sort.SliceStable(eventList, func(i, j int) bool {
+		first, _ := time.Parse(time.RFC3339, eventList[i].Timestamp)
+		second, _ := time.Parse(time.RFC3339, eventList[j].Timestamp)
+		return first.Before(second)
+	})
+	if eventList == nil {
+		eventList = []trackingEvent{}
+	}
+
+	jsonAsBytes, _ := json.Marshal(eventList)
+	return jsonAsBytes, nil
+}
+
+// blEndorsementChain is the order the bill of lading travels in, from shipper through the banks to the consignee
+func blEndorsementChain(contractDetails contract) []string {
+	return []string{
+		contractDetails.SellerDetails.Seller.UserId,
+		contractDetails.SellerDetails.SellerBank.UserId,
+		contractDetails.BuyerDetails.BuyerBank.UserId,
+		contractDetails.BuyerDetails.Buyer.UserId,
+	}
+}
+
+func issueBillOfLading(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var blDetails billOfLading
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if blCarrier(contractDetails) != userId {
+		return nil, errors.New("Only the carrier of the sea leg can issue the bill of lading")
+	} else if contractDetails.ContractStatus != Ready_For_Shipment && contractDetails.ContractStatus != Shipment_Inprogress {
+		return nil, errors.New("Bill of lading can only be issued once the goods are ready for shipment")
+	} else if contractDetails.BLNumber != "" {
+		return nil, errors.New("Contract already has bill of lading " + contractDetails.BLNumber)
+	}
+
+	err := json.Unmarshal([]byte(args[2]), &blDetails)
+	if err != nil {
+		return nil, errors.New("Invalid bill of lading")
+	}
+	if blDetails.BLNumber == "" {
+		return nil, errors.New("Bill of lading number is mandatory")
+	} else if blDetails.PortOfLoading == "" || blDetails.PortOfDischarge == "" {
+		return nil, errors.New("Ports of loading and discharge are mandatory")
+	}
+	if _, found := getBillOfLadingDetails(stub, blDetails.BLNumber); found {
+		return nil, errors.New("Bill of lading number " + blDetails.BLNumber + " already exists")
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	blDetails.ContractId = contractId
+	blDetails.Carrier = userId
+	blDetails.Shipper = contractDetails.SellerDetails.Seller.UserId
+	blDetails.Consignee = contractDetails.BuyerDetails.Buyer.UserId
+	blDetails.IssueDate = today
+	blDetails.Holder = blDetails.Shipper
+	blDetails.Status = BL_Status_Issued
+	blDetails.Endorsements = []blEndorsement{}
+	blDetails.SurrenderDate = ""
+
+	ok := updateBillOfLadingDetails(stub, blDetails)
+	if !ok {
+		return nil, errors.New("Error in saving bill of lading")
+	}
+
+	contractDetails.BLNumber = blDetails.BLNumber
+	contractDetails.IsBillOfLedingAttached = true
+	contractDetails.LastUpdatedDate = today
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func endorseBillOfLading(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	blNumber := args[1]
+	endorseeId := args[2]
+
+	blDetails, found := getBillOfLadingDetails(stub, blNumber)
+	if !found {
+		return nil, errors.New("Bill of lading " + blNumber + " not found")
+	} else if blDetails.Status != BL_Status_Issued {
+		return nil, errors.New("Bill of lading " + blNumber + " is " + blDetails.Status)
+	} else if blDetails.Holder != userId {
+		return nil, errors.New("Only the current holder can endorse the bill of lading")
+	}
+
+	//Endorsement only moves forward along the chain
+	contractDetails, _ := getContractDetails(stub, blDetails.ContractId)
+	holderIndex := -1
+	endorseeIndex := -1
+	for i, element := range blEndorsementChain(contractDetails) {
+		if element == userId && holderIndex == -1 {
+			holderIndex = i
+		}
+		if element == endorseeId {
+			endorseeIndex = i
+		}
+	}
+	if endorseeIndex <= holderIndex {
+		return nil, errors.New("Bill of lading can only be endorsed on to the seller's bank, the buyer's bank or the consignee")
+	}
+	//The buyer's bank holds the bill as collateral until it releases it to the consignee
+	if endorseeId == contractDetails.BuyerDetails.Buyer.UserId && userId != contractDetails.BuyerDetails.BuyerBank.UserId {
+		return nil, errors.New("Only the buyer's bank can endorse the bill of lading to the consignee")
+	}
+
+	blDetails.Endorsements = append(blDetails.Endorsements, blEndorsement{
+		EndorsementNumber: len(blDetails.Endorsements) + 1,
+		From:              userId,
+		To:                endorseeId,
+		EndorsementDate:   time.Now().Local().Format(dateFormat),
+	})
+	blDetails.Holder = endorseeId
+
+	ok := updateBillOfLadingDetails(stub, blDetails)
+	if !ok {
+		return nil, errors.New("Error in updating bill of lading")
+	}
+
+	return nil, nil
+}
+
+func surrenderBillOfLading(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	userId := args[0]
+	blNumber := args[1]
+
+	blDetails, found := getBillOfLadingDetails(stub, blNumber)
+	if !found {
+		return nil, errors.New("Bill of lading " + blNumber + " not found")
+	} else if blDetails.Status != BL_Status_Issued {
+		return nil, errors.New("Bill of lading " + blNumber + " is " + blDetails.Status)
+	} else if blDetails.Holder != userId {
+		return nil, errors.New("Only the current holder can surrender the bill of lading")
+	} else if blDetails.Holder != blDetails.Consignee {
+		return nil, errors.New("Bill of lading can only be surrendered by the consignee once endorsed through the banks")
+	}
+
+	blDetails.Status = BL_Status_Surrendered
+	blDetails.SurrenderDate = time.Now().Local().Format(dateFormat)
+
+	ok := updateBillOfLadingDetails(stub, blDetails)
+	if !ok {
+		return nil, errors.New("Error in updating bill of lading")
+	}
+
+	return nil, nil
+}
+
+func getBillOfLading(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	blNumber := args[0]
+	userId := args[1]
+
+	blDetails, found := getBillOfLadingDetails(stub, blNumber)
+	if !found {
+		return nil, errors.New("Bill of lading " + blNumber + " not found")
+	}
+	contractDetails, _ := getContractDetails(stub, blDetails.ContractId)
+	if len(getContractRoles(contractDetails, userId)) == 0 {
+		return nil, errors.New("Only contract parties can read the bill of lading")
+	}
+
+	jsonAsBytes, _ := json.Marshal(blDetails)
+	return jsonAsBytes, nil
+}
+
+// riskTransferStatus is where the seller's delivery obligation ends, contracts without Incoterm rules keep Ready For Shipment
+func riskTransferStatus(contractDetails contract) string {
+	if contractDetails.RiskTransfer == nil {
+		return Ready_For_Shipment
+	}
+	return contractDetails.RiskTransfer.RiskTransferStatus
+}
+
+// requiredTradeDocuments lists each document the Incoterm calls for, who supplies it and the status it is needed by
+func requiredTradeDocuments(contractDetails contract) [][3]string {
+	var documentList [][3]string
+	if contractDetails.RiskTransfer == nil {
+		return documentList
+	}
+
+	rule := contractDetails.RiskTransfer.incotermRule
+	if rule.InsuranceBy != "" {
+		documentList = append(documentList, [3]string{Document_Insurance, rule.InsuranceBy, Shipment_Inprogress})
+	}
+	documentList = append(documentList, [3]string{Document_ExportDeclaration, rule.ExportClearanceBy, Shipment_Inprogress})
+	documentList = append(documentList, [3]string{Document_ImportDeclaration, rule.ImportClearanceBy, Shipment_Delivered})
+	return documentList
+}
+
+func hasTradeDocument(contractDetails contract, documentType string) bool {
+	for _, element := range contractDetails.TradeDocuments {
+		if element.DocumentType == documentType {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTradeDocuments(contractDetails contract) error {
+	for _, element := range requiredTradeDocuments(contractDetails) {
+		if element[2] == contractDetails.ContractStatus && !hasTradeDocument(contractDetails, element[0]) {
+			return errors.New(element[0] + " must be supplied by the " + element[1] + " before " + contractDetails.ContractStatus)
+		}
+	}
+	return nil
+}
+
+func submitTradeDocument(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	documentType := args[2]
+	documentBlob := args[3]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	responsible := ""
+	for _, element := range requiredTradeDocuments(contractDetails) {
+		if element[0] == documentType {
+			responsible = element[1]
+		}
+	}
+	if responsible == "" {
+		return nil, errors.New("Incoterm " + contractDetails.DeliveryDetails.Incoterm + " does not call for " + documentType)
+	} else if contractParties(contractDetails)[responsible].UserId != userId {
+		return nil, errors.New(documentType + " must be supplied by the " + responsible)
+	} else if hasTradeDocument(contractDetails, documentType) {
+		return nil, errors.New(documentType + " is already supplied")
+	}
+
+	ok, err := insertAttachmentDetails(stub, contractId, documentType, documentBlob)
+	if !ok {
+		if err == nil {
+			err = errors.New("Error in inserting attachment")
+		}
+		return nil, err
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	contractDetails.TradeDocuments = append(contractDetails.TradeDocuments, tradeDocument{DocumentType: documentType, SubmittedBy: userId, SubmittedDate: today})
+	contractDetails.LastUpdatedDate = today
+	ok = updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func validateConditionRules(rules []conditionRule) (bool, error) {
+	metrics := make(map[string]bool)
+	for _, element := range rules {
+		if element.Metric != Metric_Temperature && element.Metric != Metric_Humidity {
+			return false, errors.New("Condition metric must be " + Metric_Temperature + " or " + Metric_Humidity)
+		} else if metrics[element.Metric] {
+			return false, errors.New("Only one condition rule is allowed per metric")
+		} else if element.Min > element.Max {
+			return false, errors.New("Condition rule minimum must not be above the maximum")
+		} else if element.Metric == Metric_Humidity && (element.Min < 0 || element.Max > 100) {
+			return false, errors.New("Humidity thresholds must be between 0 and 100 percent")
+		}
+		metrics[element.Metric] = true
+	}
+	return true, nil
+}
+
+// conditionBreaches checks a reading against the contract thresholds, a metric the sensor did not report is not a breach
+func conditionBreaches(rules []conditionRule, reading conditionReading) []conditionBreach {
+	breaches := []conditionBreach{}
+	for _, element := range rules {
+		value := reading.Temperature
+		if element.Metric == Metric_Humidity {
+			value = reading.Humidity
+		}
+		if value != nil && (*value < element.Min || *value > element.Max) {
+			breaches = append(breaches, conditionBreach{Metric: element.Metric, Value: *value, Min: element.Min, Max: element.Max})
+		}
+	}
+	return breaches
+}
+
+func registerSensor(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 4 {
+		return nil, errors.New("Incorrect number of arguments. Need 4 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	sensorId := strings.TrimSpace(args[2])
+	publicKeyPEM := args[3]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if contractDetails.DeliveryDetails.TransporterDetails.UserId != userId && !isLegTransporter(contractDetails, userId) {
+		return nil, errors.New("Only a transporter carrying the goods can register sensors")
+	} else if len(contractDetails.ConditionRules) == 0 {
+		return nil, errors.New("Contract has no condition rules to monitor")
+	} else if sensorId == "" {
+		return nil, errors.New("Sensor id is mandatory")
+	}
+	if _, found := getSensorDetails(stub, sensorId); found {
+		return nil, errors.New("Sensor " + sensorId + " is already registered")
+	}
+	_, err := parsePublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := updateSensorDetails(stub, sensorDevice{
+		SensorId:       sensorId,
+		ContractId:     contractId,
+		RegisteredBy:   userId,
+		PublicKey:      publicKeyPEM,
+		RegisteredDate: time.Now().Local().Format(dateFormat),
+	})
+	if !ok {
+		return nil, errors.New("Error in registering sensor")
+	}
+
+	return nil, nil
+}
+
+// ingestSensorReading takes a reading signed by the sensor's key over the SHA-256 of the reading JSON as submitted
+func ingestSensorReading(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	var reading conditionReading
+
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	sensorId := args[0]
+	readingJSON := args[1]
+	signatureAsBase64 := args[2]
+
+	sensor, found := getSensorDetails(stub, sensorId)
+	if !found {
+		return nil, errors.New("Sensor " + sensorId + " is not registered")
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureAsBase64)
+	if err != nil {
+		return nil, errors.New("Signature must be base64 encoded")
+	}
+	hash := sha256.Sum256([]byte(readingJSON))
+	if !verifySignature(sensor.PublicKey, hash[:], signature) {
+		return nil, errors.New("Sensor reading signature does not verify")
+	}
+
+	err = json.Unmarshal([]byte(readingJSON), &reading)
+	if err != nil {
+		return nil, errors.New("Invalid sensor reading")
+	}
+	if reading.ContractId != sensor.ContractId {
+		return nil, errors.New("Sensor " + sensorId + " is not registered for contract " + reading.ContractId)
+	} else if reading.Sequence <= sensor.LastSequence {
+		return nil, errors.New("Sensor reading sequence must increase")
+	} else if reading.Temperature == nil && reading.Humidity == nil {
+		return nil, errors.New("Sensor reading has no measurements")
+	}
+	readingTime, err := time.Parse(time.RFC3339, reading.Timestamp)
+	if err != nil {
+		return nil, errors.New("Sensor reading timestamp must be in RFC 3339 format")
+	} else if readingTime.After(time.Now()) {
+		return nil, errors.New("Sensor reading timestamp must not be in the future")
+	}
+
+	contractDetails, _ := getContractDetails(stub, reading.ContractId)
+	if contractDetails.ContractStatus != Ready_For_Shipment && contractDetails.ContractStatus != Shipment_Inprogress {
+		return nil, errors.New("Sensor readings are only accepted while the goods are in transit")
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	reading.SensorId = sensorId
+	reading.Signature = signatureAsBase64
+	reading.Breaches = conditionBreaches(contractDetails.ConditionRules, reading)
+	reading.RecordedDate = today
+
+	ok := insertConditionReading(stub, reading)
+	if !ok {
+		return nil, errors.New("Error in recording sensor reading")
+	}
+
+	sensor.LastSequence = reading.Sequence
+	ok = updateSensorDetails(stub, sensor)
+	if !ok {
+		return nil, errors.New("Error in updating sensor")
+	}
+
+	//A breach stays flagged on the contract once seen
+	if len(reading.Breaches) > 0 && !contractDetails.ConditionBreached {
+		contractDetails.ConditionBreached = true
+		contractDetails.FirstBreachAt = reading.Timestamp
+		contractDetails.LastUpdatedDate = today
+		ok = updateContractListByContractID(stub, reading.ContractId, contractDetails)
+		if !ok {
+			return nil, errors.New("Error in updating contract list")
+		}
+	}
+
+	return nil, nil
+}
+
+func breachedReadings(stub shim.ChaincodeStubInterface, contractId string) []conditionReading {
+	readingList := []conditionReading{}
+	for _, element := range getConditionReadingList(stub, contractId) {
+		if len(element.Breaches) > 0 {
+			readingList = append(readingList, element)
+		}
+	}
+	return readingList
+}
+
+func getConditionReadings(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+	breachesOnly := args[2] == "true"
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if len(getContractRoles(contractDetails, userId)) == 0 {
+		return nil, errors.New("Only contract parties can read condition readings")
+	}
+
+	readingList := getConditionReadingList(stub, contractId)
+	if breachesOnly {
+		readingList = breachedReadings(stub, contractId)
+	}
+	if readingList == nil {
+		readingList = []conditionReading{}
+	}
+
+	jsonAsBytes, _ := json.Marshal(readingList)
+	return jsonAsBytes, nil
+}
+
+// raiseDispute opens a dispute on the contract with the breached sensor readings attached as evidence
+func raiseDispute(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	reason := strings.TrimSpace(args[2])
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if len(getContractRoles(contractDetails, userId)) == 0 {
+		return nil, errors.New("Only contract parties can raise a dispute")
+	} else if reason == "" {
+		return nil, errors.New("Dispute reason is mandatory")
+	}
+
+	disputeList := getDisputeList(stub, contractId)
+	disputeDetails := dispute{
+		DisputeNumber: len(disputeList) + 1,
+		ContractId:    contractId,
+		RaisedBy:      userId,
+		Reason:        reason,
+		Status:        Dispute_Open,
+		RaisedDate:    time.Now().Local().Format(dateFormat),
+		Evidence:      breachedReadings(stub, contractId),
+	}
+
+	ok := updateDisputeList(stub, contractId, append(disputeList, disputeDetails))
+	if !ok {
+		return nil, errors.New("Error in recording dispute")
+	}
+
+	return []byte(strconv.Itoa(disputeDetails.DisputeNumber)), nil
+}
+
+func getContractDisputes(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	if len(getContractRoles(contractDetails, userId)) == 0 {
+		return nil, errors.New("Only contract parties can read disputes")
+	}
+
+	disputeList := getDisputeList(stub, contractId)
+	if disputeList == nil {
+		disputeList = []dispute{}
+	}
+
+	jsonAsBytes, _ := json.Marshal(disputeList)
+	return jsonAsBytes, nil
+}
+
+func validateTransportLegs(contractDetails contract, rule incotermRule) (bool, error) {
+	legs := contractDetails.DeliveryDetails.TransportLegs
+	if contractDetails.DeliveryDetails.TransporterDetails.UserId != legs[0].Transporter.UserId {
+		return false, errors.New("TransporterDetails must be the transporter of the first leg")
+	}
+
+	seaLeg := false
+	var previousArrival time.Time
+	for i, element := range legs {
+		leg := strconv.Itoa(i + 1)
+		if element.Transporter.UserId == "" {
+			return false, errors.New("Transporter is mandatory on leg " + leg)
+		} else if !Transport_Modes[element.Mode] {
+			return false, errors.New("Transport mode on leg " + leg + " must be road, rail, sea or air")
+		} else if strings.TrimSpace(element.Origin) == "" || strings.TrimSpace(element.Destination) == "" {
+			return false, errors.New("Origin and destination are mandatory on leg " + leg)
+		}
+		departure, err := time.Parse(time.RFC3339, element.DepartureDate)
+		if err != nil {
+			return false, errors.New("Departure date on leg " + leg + " must be in RFC 3339 format")
+		}
+		arrival, err := time.Parse(time.RFC3339, element.ArrivalDate)
+		if err != nil {
+			return false, errors.New("Arrival date on leg " + leg + " must be in RFC 3339 format")
+		} else if arrival.Before(departure) {
+			return false, errors.New("Arrival date on leg " + leg + " is before its departure")
+		}
+
+		//Each leg picks up where the previous one ended
+		if i > 0 {
+			if !strings.EqualFold(strings.TrimSpace(legs[i-1].Destination), strings.TrimSpace(element.Origin)) {
+				return false, errors.New("Leg " + leg + " must start at " + legs[i-1].Destination)
+			} else if departure.Before(previousArrival) {
+				return false, errors.New("Leg " + leg + " departs before leg " + strconv.Itoa(i) + " arrives")
+			}
+		}
+		previousArrival = arrival
+		seaLeg = seaLeg || element.Mode == "sea"
+	}
+
+	if rule.SeaOnly && !seaLeg {
+		return false, errors.New("Incoterm " + rule.Code + " needs a sea leg")
+	}
+	return true, nil
+}
+
+// legTransporters returns each transporter carrying a leg, once
+func legTransporters(contractDetails contract) []string {
+	var transporters []string
+	seen := make(map[string]bool)
+	for _, element := range contractDetails.DeliveryDetails.TransportLegs {
+		if !seen[element.Transporter.UserId] {
+			seen[element.Transporter.UserId] = true
+			transporters = append(transporters, element.Transporter.UserId)
+		}
+	}
+	return transporters
+}
+
+func isLegTransporter(contractDetails contract, userId string) bool {
+	for _, element := range contractDetails.DeliveryDetails.TransportLegs {
+		if element.Transporter.UserId == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// blCarrier issues the bill of lading, the first sea leg's transporter on multi-leg shipments
+func blCarrier(contractDetails contract) string {
+	for _, element := range contractDetails.DeliveryDetails.TransportLegs {
+		if element.Mode == "sea" {
+			return element.Transporter.UserId
+		}
+	}
+	return contractDetails.DeliveryDetails.TransporterDetails.UserId
+}
+
+// currentCarrier is the transporter holding the goods, the leg in transit for multi-leg shipments
+func currentCarrier(contractDetails contract) string {
+	for _, element := range contractDetails.DeliveryDetails.TransportLegs {
+		if element.Status == Leg_Status_InTransit {
+			return element.Transporter.UserId
+		}
+	}
+	if len(contractDetails.DeliveryDetails.TransportLegs) != 0 {
+		return ""
+	}
+	return contractDetails.DeliveryDetails.TransporterDetails.UserId
+}
+
+// advanceTransportLegs starts the first leg on shipment and completes the last on delivery, on a copy of the legs
+func advanceTransportLegs(contractDetails contract, today string) ([]transportLeg, error) {
+	legs := append([]transportLeg{}, contractDetails.DeliveryDetails.TransportLegs...)
+	last := len(legs) - 1
+
+	if contractDetails.ContractStatus == Shipment_Inprogress {
+		legs[0].Status = Leg_Status_InTransit
+		legs[0].StartedDate = today
+	} else if contractDetails.ContractStatus == Shipment_Delivered {
+		if legs[last].Status != Leg_Status_InTransit {
+			return nil, errors.New("Goods must be handed over to the last leg before " + Shipment_Delivered)
+		}
+		legs[last].Status = Leg_Status_Completed
+		legs[last].CompletedDate = today
+		legs[last].HandedOverTo = contractDetails.BuyerDetails.Buyer.UserId
+	}
+	return legs, nil
+}
+
+// confirmHandover is called by the next leg's transporter once it has taken the goods over
+func confirmHandover(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 3 {
+		return nil, errors.New("Incorrect number of arguments. Need 3 arguments")
+	}
+
+	userId := args[0]
+	contractId := args[1]
+	legNumber, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, errors.New("Leg number must be a number")
+	}
+
+	contractDetails, _ := getContractDetails(stub, contractId)
+	legs := append([]transportLeg{}, contractDetails.DeliveryDetails.TransportLegs...)
+	if contractDetails.ContractStatus != Shipment_Inprogress {
+		return nil, errors.New("Handovers can only be confirmed during shipment")
+	} else if legNumber < 1 || legNumber >= len(legs) {
+		return nil, errors.New("Leg " + args[2] + " has no following leg to hand over to")
+	}
+
+	leg := &legs[legNumber-1]
+	next := &legs[legNumber]
+	if leg.Status != Leg_Status_InTransit {
+		return nil, errors.New("Leg " + args[2] + " is " + leg.Status)
+	} else if next.Transporter.UserId != userId {
+		return nil, errors.New("Only the transporter of leg " + strconv.Itoa(legNumber+1) + " can confirm the handover")
+	}
+
+	today := time.Now().Local().Format(dateFormat)
+	leg.Status = Leg_Status_Completed
+	leg.CompletedDate = today
+	leg.HandedOverTo = userId
+	next.Status = Leg_Status_InTransit
+	next.StartedDate = today
+
+	contractDetails.DeliveryDetails.TransportLegs = legs
+	contractDetails.LastUpdatedDate = today
+	ok := updateContractListByContractID(stub, contractId, contractDetails)
+	if !ok {
+		return nil, errors.New("Error in updating contract list")
+	}
+
+	return nil, nil
+}
+
+func getScreeningResults(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errors.New("Incorrect number of arguments. Need 2 arguments")
+	}
+
+	contractId := args[0]
+	userId := args[1]
+
+	//Watch-list hits are only shown to compliance and the contract's own parties
+	if !hasUserRole(stub, userId, Role_Admin) && !hasUserRole(stub, userId, Role_Compliance) {
+		contractDetails, _ := getContractDetails(stub, contractId)
+		if len(getContractRoles(contractDetails, userId)) == 0 {
+			return nil, errors.New("Only admin, compliance and contract parties can read screening results")
+		}
+	}
+
+	screeningList := getScreeningList(stub, contractId)
+	jsonAsBytes, _ := json.Marshal(screeningList)
+	return jsonAsBytes, nil
+}
+
 /* Commented becouse v0.6 does not support it
 func mandatoryFieldCheck(contractDetails contract) (bool, error) {
 
@@ -0,0 +1,1885 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestCheckTradeAmounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"no total", `{}`, false},
+		{"money total", `{"totalTradeAmount":{"amount":"42.00","currency":"USD"}}`, false},
+		{"plain number total", `{"totalTradeAmount":42}`, false},
+		{"wrong plain total", `{"totalTradeAmount":41.99}`, true},
+		{"wrong currency", `{"totalTradeAmount":{"amount":"42.00","currency":"EUR"}}`, true},
+	}
+
+	for _, test := range tests {
+		contractDetails := testContract()
+		err := json.Unmarshal([]byte(test.json), &contractDetails)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		_, err = checkTradeAmounts(contractDetails)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v", test.name, err)
+		}
+	}
+}
+
+func TestLegacyContractAmounts(t *testing.T) {
+	stub := newTestStub(t)
+	contractId := saveTestContract(t, stub, testContract())
+
+	//A row written before amounts carried their currency
+	legacy := readContract(t, stub, contractId)
+	legacy.PricingHash = ""
+	legacyAsBytes, _ := json.Marshal(legacy)
+	legacyAsBytes = []byte(strings.Replace(string(legacyAsBytes), `{"amount":"42.00","currency":"USD"}`, `42`, 1))
+	stub.MockTransactionStart("legacy")
+	stub.ReplaceRow("contractDetails", shim.Row{Columns: []*shim.Column{
+		&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+		&shim.Column{Value: &shim.Column_Bytes{Bytes: legacyAsBytes}},
+	}})
+	stub.MockTransactionEnd("legacy")
+
+	contractDetails := readContract(t, stub, contractId)
+	if contractDetails.TotalTradeAmount != (money{Minor: 4200, Currency: "USD"}) {
+		t.Fatalf("legacy total %+v", contractDetails.TotalTradeAmount)
+	}
+	_, err := contractDetails.TotalTradeAmount.Add(money{Minor: 100, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSanctionsScreening(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    string
+		wantResult string
+		wantStatus string
+	}{
+		{"clear", `[{"entryType":"name","value":"Someone Else","listName":"OFAC","action":"block"}]`, Screening_Clear, Contract_Created},
+		{"name blocked", `[{"entryType":"name","value":"buyer  ltd","listName":"OFAC","action":"block"}]`, Screening_Blocked, Contract_Blocked},
+		{"identifier blocked", `[{"entryType":"identifier","value":"SELLERBANK","listName":"OFAC","action":"block"}]`, Screening_Blocked, Contract_Blocked},
+		{"country review", `[{"entryType":"country","value":"Germany","listName":"EU","action":"review"}]`, Screening_Review, Contract_Created},
+		{"block wins over review", `[{"entryType":"country","value":"germany","listName":"EU","action":"review"},{"entryType":"name","value":"Seller Co","listName":"UN","action":"block"}]`, Screening_Blocked, Contract_Blocked},
+	}
+
+	for _, test := range tests {
+		stub := newTestStub(t)
+		invoke(t, stub, "importWatchList", "admin", "1", test.entries)
+		contractId := saveTestContract(t, stub, testContract())
+
+		contractDetails := readContract(t, stub, contractId)
+		if contractDetails.ScreeningStatus != test.wantResult || contractDetails.ContractStatus != test.wantStatus {
+			t.Errorf("%s: screening %s status %s, want %s and %s", test.name, contractDetails.ScreeningStatus, contractDetails.ContractStatus, test.wantResult, test.wantStatus)
+		}
+	}
+}
+
+func TestScreeningReviewHoldsTransitions(t *testing.T) {
+	stub := newTestStub(t)
+	invoke(t, stub, "initializeUser", "officer")
+	invoke(t, stub, "assignUserRole", "admin", "officer", Role_Compliance)
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"country","value":"germany","listName":"EU","action":"review"}]`)
+	contractId := saveTestContract(t, stub, testContract())
+
+	_, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId)
+	if err == nil {
+		t.Fatal("transition allowed while pending compliance review")
+	}
+	_, err = invokeErr(stub, "resolveScreeningReview", "buyer", contractId, Screening_Clear)
+	if err == nil {
+		t.Fatal("buyer resolved its own screening review")
+	}
+
+	invoke(t, stub, "resolveScreeningReview", "officer", contractId, Screening_Blocked)
+	if status := readContract(t, stub, contractId).ContractStatus; status != Contract_Blocked {
+		t.Fatalf("status %s after blocking review", status)
+	}
+	_, err = invokeErr(stub, "UpdateContractStatus", "buyer", contractId)
+	if err == nil {
+		t.Fatal("transition allowed on a blocked contract")
+	}
+}
+
+func TestGetScreeningResultsAccess(t *testing.T) {
+	stub := newTestStub(t)
+	invoke(t, stub, "initializeUser", "officer")
+	invoke(t, stub, "initializeUser", "outsider")
+	invoke(t, stub, "assignUserRole", "admin", "officer", Role_Compliance)
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"country","value":"germany","listName":"EU","action":"review"}]`)
+	contractId := saveTestContract(t, stub, testContract())
+
+	tests := []struct {
+		userId  string
+		allowed bool
+	}{
+		{"admin", true},
+		{"officer", true},
+		{"buyer", true},
+		{"transporter", true},
+		{"outsider", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		result, err := stub.MockQuery("getScreeningResults", []string{contractId, test.userId})
+		if (err == nil) != test.allowed {
+			t.Errorf("%q: error = %v", test.userId, err)
+			continue
+		}
+		if !test.allowed {
+			continue
+		}
+		var screeningList []screeningResult
+		json.Unmarshal(result, &screeningList)
+		if len(screeningList) != 1 || len(screeningList[0].Matches) == 0 {
+			t.Errorf("%q: got %s", test.userId, result)
+		}
+	}
+}
+
+func TestTransferSettlementFunds(t *testing.T) {
+	tests := []struct {
+		name       string
+		payerFunds money
+		payeeFunds money
+		amount     money
+		wantErr    bool
+		wantPayer  int64
+		wantPayee  int64
+	}{
+		{"transfer", money{10000, "USD"}, money{}, money{2500, "USD"}, false, 7500, 2500},
+		{"whole balance", money{10000, "USD"}, money{500, "USD"}, money{10000, "USD"}, false, 0, 10500},
+		{"insufficient funds", money{10000, "USD"}, money{}, money{10001, "USD"}, true, 0, 0},
+		{"other currency", money{10000, "USD"}, money{}, money{100, "EUR"}, true, 0, 0},
+		{"payee overflow", money{10000, "USD"}, money{9223372036854775807, "USD"}, money{1, "USD"}, true, 0, 0},
+	}
+
+	for _, test := range tests {
+		stub := shim.NewMockStub("settlement", new(DTC_Chaincode))
+		_, err := stub.MockInit("init", "init", []string{"admin"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stub.MockTransactionStart(test.name)
+		if !test.payerFunds.IsZero() {
+			adjustSettlementBalance(stub, "payer", test.payerFunds)
+		}
+		if !test.payeeFunds.IsZero() {
+			adjustSettlementBalance(stub, "payee", test.payeeFunds)
+		}
+		err = transferSettlementFunds(stub, "payer", "payee", test.amount)
+		stub.MockTransactionEnd(test.name)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v", test.name, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		payer := settlementBalance(getSettlementBalanceList(stub, "payer"), test.amount.Currency)
+		payee := settlementBalance(getSettlementBalanceList(stub, "payee"), test.amount.Currency)
+		if payer.Minor != test.wantPayer || payee.Minor != test.wantPayee {
+			t.Errorf("%s: payer %d payee %d, want %d and %d", test.name, payer.Minor, payee.Minor, test.wantPayer, test.wantPayee)
+		}
+	}
+}
+
+func TestScreeningBlocksPayment(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToInvoiced(t, stub, contractId, parties)
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"name","value":"Buyer Ltd","listName":"OFAC","action":"block"}]`)
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, `{"bankReference":"R1","valueDate":"2026-10-19"}`)
+
+	contractDetails := readContract(t, stub, contractId)
+	if contractDetails.ContractStatus != Contract_Blocked {
+		t.Fatalf("status %s, want %s", contractDetails.ContractStatus, Contract_Blocked)
+	}
+	if balance := settlementBalanceOf(t, stub, "sellerbank"); balance != "1000.00" {
+		t.Errorf("seller bank balance %s, want 1000.00", balance)
+	}
+	if balance := settlementBalanceOf(t, stub, "seller"); balance != "0" {
+		t.Errorf("seller balance %s, want 0", balance)
+	}
+	if payments := getPaymentList(stub, contractId); len(payments) != 0 {
+		t.Errorf("payments recorded on a blocked contract: %+v", payments)
+	}
+}
+
+func TestCalculateFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    feeRule
+		base    money
+		want    int64
+		wantErr bool
+	}{
+		{"percentage", feeRule{Percentage: 0.5}, money{1000000, "USD"}, 5000, false},
+		{"minimum", feeRule{Percentage: 0.1, MinimumAmount: money{5000, "USD"}}, money{100000, "USD"}, 5000, false},
+		{"fixed on top of minimum", feeRule{Percentage: 0.1, MinimumAmount: money{5000, "USD"}, FixedAmount: money{2500, "USD"}}, money{100000, "USD"}, 7500, false},
+		{"fixed only", feeRule{FixedAmount: money{2500, "USD"}}, money{100000, "USD"}, 2500, false},
+		{"rounds to minor units", feeRule{Percentage: 0.125}, money{100, "JPY"}, 0, false},
+		{"overflow", feeRule{Percentage: 100, FixedAmount: money{2, "USD"}}, money{9223372036854775807, "USD"}, 0, true},
+	}
+
+	for _, test := range tests {
+		fee, err := calculateFee(test.rule, test.base)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v", test.name, err)
+			continue
+		}
+		if !test.wantErr && (fee.Minor != test.want || fee.Currency != test.base.Currency) {
+			t.Errorf("%s: got %s %s, want %d minor units", test.name, fee, fee.Currency, test.want)
+		}
+	}
+}
+
+func TestBankFees(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	_, err := invokeErr(stub, "setFeeSchedule", "buyerbank", `[]`)
+	if err == nil {
+		t.Fatal("fee schedule set by a user without the bank role")
+	}
+	invoke(t, stub, "assignUserRole", "admin", "buyerbank", Role_Bank)
+	invoke(t, stub, "assignUserRole", "admin", "sellerbank", Role_Bank)
+	_, err = invokeErr(stub, "setFeeSchedule", "buyerbank", `[{"event":"lcIssuance","currency":"USD","percentage":1,"minimumAmount":{"amount":"5","currency":"EUR"}}]`)
+	if err == nil {
+		t.Fatal("fee rule accepted with a minimum in another currency")
+	}
+	invoke(t, stub, "setFeeSchedule", "buyerbank", `[{"event":"lcIssuance","currency":"USD","percentage":1,"minimumAmount":{"amount":"5","currency":"USD"}}]`)
+	invoke(t, stub, "setFeeSchedule", "sellerbank", `[{"event":"lcAdvising","currency":"USD","percentage":0.5,"fixedAmount":{"amount":"1","currency":"USD"}}]`)
+
+	contractDetails := testContract()
+	contractDetails.ChargesClause = Charges_Ben
+	contractId := saveTestContract(t, stub, contractDetails)
+	advanceToLCApproved(t, stub, contractId, parties)
+
+	//Issuance is the 5.00 minimum, advising is 0.21 plus 1.00, both borne by the seller
+	var statement feeStatement
+	json.Unmarshal(query(t, stub, "getFeeStatement", contractId, "seller"), &statement)
+	if len(statement.Charges) != 2 || len(statement.Total) != 1 || statement.Total[0].String() != "6.21" {
+		t.Errorf("seller fee statement %+v", statement)
+	}
+	json.Unmarshal(query(t, stub, "getFeeStatement", contractId, "buyer"), &statement)
+	if len(statement.Charges) != 0 {
+		t.Errorf("buyer charged %+v", statement.Charges)
+	}
+}
+
+func TestScreeningBlocksBankFees(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "assignUserRole", "admin", "buyerbank", Role_Bank)
+	invoke(t, stub, "setFeeSchedule", "buyerbank", `[{"event":"lcIssuance","currency":"USD","fixedAmount":{"amount":"5","currency":"USD"}}]`)
+	contractId := saveTestContract(t, stub, testContract())
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, `{"lcNumber":"LC-1","amount":{"amount":"42.00","currency":"USD"},"tolerancePercentage":5,"expiryDate":"2099-01-01","expiryPlace":"Hamburg","latestShipmentDate":"2098-12-01","requiredDocuments":["Invoice"]}`)
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"name","value":"Seller Co","listName":"UN","action":"block"}]`)
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+
+	if status := readContract(t, stub, contractId).ContractStatus; status != Contract_Blocked {
+		t.Fatalf("status %s, want %s", status, Contract_Blocked)
+	}
+	var statement feeStatement
+	json.Unmarshal(query(t, stub, "getFeeStatement", contractId, "buyer"), &statement)
+	if len(statement.Charges) != 0 {
+		t.Errorf("fees charged on a blocked transition: %+v", statement.Charges)
+	}
+}
+
+func TestLateDeliveryPenalties(t *testing.T) {
+	tests := []struct {
+		name           string
+		rules          string
+		wantPercentage float64
+		wantAmount     string
+	}{
+		{"default rule", "", 5, "39.90"},
+		{"organisation rules", `[{"ruleId":"pd","ruleType":"perDay","graceDays":1,"percentagePerDay":1.5,"capPercentage":10},{"ruleId":"eb","ruleType":"earlyBonus","percentagePerDay":0.5,"capPercentage":2}]`, 3, "40.74"},
+	}
+
+	for _, test := range tests {
+		stub := newTestStub(t)
+		parties := newTestParties(t, stub)
+		if test.rules != "" {
+			invoke(t, stub, "setOrganisationPenaltyRules", "admin", "seller", test.rules)
+		}
+		contractId := saveTestContract(t, stub, testContract())
+		moveDeliveryDate(t, stub, contractId, time.Now().AddDate(0, 0, -3))
+		advanceToLCApproved(t, stub, contractId, parties)
+		invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+		if readContract(t, stub, contractId).DiscountPercentage != 0 {
+			t.Errorf("%s: penalty applied before risk transfer", test.name)
+		}
+
+		invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+		contractDetails := readContract(t, stub, contractId)
+		if contractDetails.DiscountPercentage != test.wantPercentage || contractDetails.DiscountedAmount.String() != test.wantAmount {
+			t.Errorf("%s: discount %v%% to %s, want %v%% to %s", test.name, contractDetails.DiscountPercentage, contractDetails.DiscountedAmount, test.wantPercentage, test.wantAmount)
+		}
+		var penalties []penaltyRecord
+		json.Unmarshal(query(t, stub, "getPenaltyRecords", contractId, "seller"), &penalties)
+		if len(penalties) == 0 || penalties[0].DaysLate != 3 {
+			t.Errorf("%s: penalty records %+v", test.name, penalties)
+		}
+	}
+}
+
+func TestPenaltyRulesAdminOnly(t *testing.T) {
+	stub := newTestStub(t)
+	_, err := invokeErr(stub, "setOrganisationPenaltyRules", "seller", "seller", `[]`)
+	if err == nil {
+		t.Fatal("seller set its own penalty rules")
+	}
+}
+
+func TestScreeningBlocksPenalties(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	moveDeliveryDate(t, stub, contractId, time.Now().AddDate(0, 0, -3))
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"identifier","value":"transporter","listName":"OFAC","action":"block"}]`)
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+
+	contractDetails := readContract(t, stub, contractId)
+	if contractDetails.ContractStatus != Contract_Blocked || contractDetails.DiscountPercentage != 0 {
+		t.Errorf("status %s discount %v%%, want %s and no discount", contractDetails.ContractStatus, contractDetails.DiscountPercentage, Contract_Blocked)
+	}
+	if penalties := getPenaltyList(stub, contractId); len(penalties) != 0 {
+		t.Errorf("penalties saved on a blocked transition: %+v", penalties)
+	}
+}
+
+func TestCreditExposure(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "setCreditLimit", "buyerbank", "buyer", "USD", "40")
+	contractId := saveTestContract(t, stub, testContract())
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, `{"lcNumber":"LC-`+contractId+`","amount":{"amount":"42.00","currency":"USD"},"tolerancePercentage":5,"expiryDate":"2099-01-01","expiryPlace":"Hamburg","latestShipmentDate":"2098-12-01","requiredDocuments":["Invoice"]}`)
+
+	//42.00 plus 5% tolerance is over the limit
+	_, err := invokeErr(stub, "UpdateContractStatus", "buyerbank", contractId)
+	if err == nil {
+		t.Fatal("letter of credit issued over the credit limit")
+	}
+	invoke(t, stub, "setCreditLimit", "buyerbank", "buyer", "USD", "50")
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+
+	var utilisation []creditUtilisation
+	json.Unmarshal(query(t, stub, "getCreditUtilisation", "buyerbank"), &utilisation)
+	if len(utilisation) != 1 || utilisation[0].Utilised.String() != "44.10" || utilisation[0].Available.String() != "5.90" {
+		t.Fatalf("utilisation %+v", utilisation)
+	}
+
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	blNumber := "BL-" + contractId
+	invoke(t, stub, "issueBillOfLading", "transporter", contractId, `{"blNumber":"`+blNumber+`","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`)
+	invoke(t, stub, "endorseBillOfLading", "seller", blNumber, "sellerbank")
+	invoke(t, stub, "endorseBillOfLading", "sellerbank", blNumber, "buyerbank")
+	invoke(t, stub, "endorseBillOfLading", "buyerbank", blNumber, "buyer")
+	invoke(t, stub, "surrenderBillOfLading", "buyer", blNumber)
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId)
+	invoke(t, stub, "createInvoice", "seller", contractId, `{}`)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, `{"bankReference":"R1","valueDate":"2026-10-19"}`)
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId, `{"bankReference":"R2","valueDate":"2026-10-19"}`)
+
+	json.Unmarshal(query(t, stub, "getCreditUtilisation", "buyerbank"), &utilisation)
+	if len(utilisation) != 1 || !utilisation[0].Utilised.IsZero() || len(utilisation[0].Exposures) != 0 {
+		t.Errorf("utilisation after settlement %+v", utilisation)
+	}
+}
+
+func TestScreeningKeepsCreditExposure(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "setCreditLimit", "buyerbank", "buyer", "USD", "50")
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToInvoiced(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, `{"bankReference":"R1","valueDate":"2026-10-19"}`)
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"identifier","value":"buyerbank","listName":"OFAC","action":"block"}]`)
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId, `{"bankReference":"R2","valueDate":"2026-10-19"}`)
+
+	if status := readContract(t, stub, contractId).ContractStatus; status != Contract_Blocked {
+		t.Fatalf("status %s, want %s", status, Contract_Blocked)
+	}
+	var utilisation []creditUtilisation
+	json.Unmarshal(query(t, stub, "getCreditUtilisation", "buyerbank"), &utilisation)
+	if len(utilisation) != 1 || utilisation[0].Utilised.String() != "44.10" {
+		t.Errorf("exposure released on a blocked payment: %+v", utilisation)
+	}
+}
+
+func TestPublicKeyRegistration(t *testing.T) {
+	stub := newTestStub(t)
+	firstKey, secondKey := newTestKey(), newTestKey()
+	_, err := invokeErr(stub, "registerPublicKey", "seller", "buyer", firstKey.publicKey)
+	if err == nil {
+		t.Fatal("first key registered by a user who is not admin")
+	}
+	invoke(t, stub, "registerPublicKey", "admin", "buyer", firstKey.publicKey)
+
+	//Rotation must be signed by the key being replaced
+	hash := sha256.Sum256([]byte(secondKey.publicKey))
+	rotation := firstKey.sign(hex.EncodeToString(hash[:]))
+	_, err = invokeErr(stub, "registerPublicKey", "admin", "buyer", secondKey.publicKey, rotation)
+	if err == nil {
+		t.Fatal("key rotated by admin")
+	}
+	_, err = invokeErr(stub, "registerPublicKey", "buyer", "buyer", secondKey.publicKey)
+	if err == nil {
+		t.Fatal("key rotated without a signature")
+	}
+	invoke(t, stub, "registerPublicKey", "buyer", "buyer", secondKey.publicKey, rotation)
+}
+
+func TestContractSignatures(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+
+	_, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId)
+	if err == nil {
+		t.Fatal("contract accepted without a signature")
+	}
+	var signingHash contractSignature
+	json.Unmarshal(query(t, stub, "getContractSigningHash", contractId), &signingHash)
+	_, err = invokeErr(stub, "UpdateContractStatus", "buyer", contractId, parties.sellerBank.sign(signingHash.ContractHash))
+	if err == nil {
+		t.Fatal("contract accepted with another party's signature")
+	}
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId, parties.buyer.sign(signingHash.ContractHash))
+
+	var signatures []contractSignature
+	json.Unmarshal(query(t, stub, "getContractSignatures", contractId), &signatures)
+	if len(signatures) != 1 || signatures[0].UserId != "buyer" || signatures[0].ContractHash != signingHash.ContractHash || signatures[0].ContractVersion != signingHash.ContractVersion {
+		t.Fatalf("signatures %+v", signatures)
+	}
+	_, err = stub.MockQuery("getSignedContractVersion", []string{contractId, strconv.Itoa(signingHash.ContractVersion), "transporter"})
+	if err == nil {
+		t.Error("signed contract version read by the transporter")
+	}
+	query(t, stub, "getSignedContractVersion", contractId, strconv.Itoa(signingHash.ContractVersion), "seller")
+}
+
+func TestScreeningBlocksSignature(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"name","value":"Buyer Ltd","listName":"OFAC","action":"block"}]`)
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+
+	if status := readContract(t, stub, contractId).ContractStatus; status != Contract_Blocked {
+		t.Fatalf("status %s, want %s", status, Contract_Blocked)
+	}
+	if signatures := getSignatureList(stub, contractId); len(signatures) != 0 {
+		t.Errorf("signature recorded for a blocked transition: %+v", signatures)
+	}
+}
+
+func TestPaymentReconciliation(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToInvoiced(t, stub, contractId, parties)
+
+	var reconciliation []paymentReconciliation
+	json.Unmarshal(query(t, stub, "getPaymentReconciliation", contractId, "buyer"), &reconciliation)
+	if len(reconciliation) == 0 || reconciliation[0].Status != Reconciliation_Missing {
+		t.Fatalf("reconciliation before payment %+v", reconciliation)
+	}
+
+	tests := []struct {
+		name    string
+		userId  string
+		payment string
+		wantErr bool
+	}{
+		{"no payment details", "sellerbank", "", true},
+		{"other currency", "sellerbank", `{"amount":{"amount":"40","currency":"EUR"},"bankReference":"R1","valueDate":"2026-10-19"}`, true},
+		{"no bank reference", "sellerbank", `{"amount":{"amount":"40","currency":"USD"},"valueDate":"2026-10-19"}`, true},
+		{"underpaid", "sellerbank", `{"amount":{"amount":"40","currency":"USD"},"bankReference":"R1","valueDate":"2026-10-19"}`, false},
+		{"reference reused", "buyerbank", `{"amount":{"amount":"50","currency":"USD"},"bankReference":"R1","valueDate":"2026-10-19"}`, true},
+		{"overpaid", "buyerbank", `{"amount":{"amount":"50","currency":"USD"},"bankReference":"R2","valueDate":"2026-10-19"}`, false},
+	}
+	for _, test := range tests {
+		args := []string{test.userId, contractId}
+		if test.payment != "" {
+			args = append(args, test.payment)
+		}
+		_, err := invokeErr(stub, "UpdateContractStatus", args...)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("%s: error = %v", test.name, err)
+		}
+	}
+
+	json.Unmarshal(query(t, stub, "getPaymentReconciliation", contractId, "seller"), &reconciliation)
+	if len(reconciliation) != 2 || reconciliation[0].Status != Reconciliation_Underpaid || reconciliation[0].Difference.String() != "-2.00" || reconciliation[1].Status != Reconciliation_Overpaid {
+		t.Errorf("reconciliation %+v", reconciliation)
+	}
+	if status := readContract(t, stub, contractId).ContractStatus; status != Payment_Completed_to_Seller_Bank {
+		t.Errorf("status %s, want %s", status, Payment_Completed_to_Seller_Bank)
+	}
+}
+
+func TestScreeningReviewKeepsBankReference(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "initializeUser", "officer")
+	invoke(t, stub, "assignUserRole", "admin", "officer", Role_Compliance)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToInvoiced(t, stub, contractId, parties)
+
+	invoke(t, stub, "importWatchList", "admin", "1", `[{"entryType":"country","value":"germany","listName":"EU","action":"review"}]`)
+	payment := `{"bankReference":"R1","valueDate":"2026-10-19"}`
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, payment)
+	if payments := getPaymentList(stub, contractId); len(payments) != 0 {
+		t.Fatalf("payment recorded while pending review: %+v", payments)
+	}
+
+	//The same payment goes through once compliance clears the contract
+	invoke(t, stub, "resolveScreeningReview", "officer", contractId, Screening_Clear)
+	invoke(t, stub, "importWatchList", "admin", "2", `[]`)
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, payment)
+	payments := getPaymentList(stub, contractId)
+	if len(payments) != 1 || payments[0].BankReference != "R1" || !payments[0].Settled {
+		t.Errorf("payments %+v", payments)
+	}
+}
+
+func TestSettlementOnPayment(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToInvoiced(t, stub, contractId, parties)
+
+	invoke(t, stub, "withdrawSettlementFunds", "admin", "sellerbank", "980", "USD")
+	payment := `{"bankReference":"R1","valueDate":"2026-10-19"}`
+	_, err := invokeErr(stub, "UpdateContractStatus", "sellerbank", contractId, payment)
+	if err == nil {
+		t.Fatal("payment settled without enough funds")
+	}
+	invoke(t, stub, "depositSettlementFunds", "admin", "sellerbank", "30", "USD")
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, payment)
+
+	if balance := settlementBalanceOf(t, stub, "sellerbank"); balance != "8.00" {
+		t.Errorf("seller bank balance %s, want 8.00", balance)
+	}
+	if balance := settlementBalanceOf(t, stub, "seller"); balance != "42.00" {
+		t.Errorf("seller balance %s, want 42.00", balance)
+	}
+}
+
+func TestDayCountFraction(t *testing.T) {
+	tests := []struct {
+		dayCount  string
+		from      string
+		to        string
+		wantDays  int
+		wantBasis int
+	}{
+		{DayCount_Act360, "2026-01-01", "2026-03-02", 60, 360},
+		{DayCount_Act365, "2026-01-01", "2026-03-01", 59, 365},
+		{DayCount_30E360, "2026-01-01", "2026-03-01", 60, 360},
+		{DayCount_30E360, "2026-01-31", "2026-02-28", 28, 360},
+		{DayCount_30E360, "2026-01-31", "2026-03-31", 60, 360},
+		{DayCount_Act365, "2024-02-28", "2024-03-01", 2, 365},
+		{"", "2026-01-01", "2026-01-31", 30, 360},
+	}
+
+	for _, test := range tests {
+		from, _ := time.Parse(dateFormat, test.from)
+		to, _ := time.Parse(dateFormat, test.to)
+		days, basis := dayCountFraction(test.dayCount, from, to)
+		if days != test.wantDays || basis != test.wantBasis {
+			t.Errorf("dayCountFraction(%s, %s, %s) = %d/%d, want %d/%d", test.dayCount, test.from, test.to, days, basis, test.wantDays, test.wantBasis)
+		}
+	}
+}
+
+func TestCalculateLateInterest(t *testing.T) {
+	contractDetails := contract{ContractId: "1", PaymentDueDate: "2026-01-01", LateInterest: &lateInterestTerms{AnnualRate: 12, DayCount: DayCount_Act360}}
+	contractDetails.TradeConditions.Currency = "USD"
+	invoiceList := []invoice{
+		{InvoiceNumber: "I1", InvoiceType: Invoice_Type_Invoice, InvoiceDate: "2025-12-01", DueDate: "2026-01-01", TotalAmount: money{360000, "USD"}},
+		{InvoiceNumber: "I2", InvoiceType: Invoice_Type_Invoice, InvoiceDate: "2026-02-01", DueDate: "2026-04-01", TotalAmount: money{100000, "USD"}},
+		{InvoiceNumber: "I3", InvoiceType: Invoice_Type_Invoice, InvoiceDate: "2026-03-05", DueDate: "2026-03-06", TotalAmount: money{100000, "USD"}},
+	}
+	paymentList := []paymentRecord{{PaymentType: Payment_Completed_to_Seller_Bank, Amount: money{180000, "USD"}, ValueDate: "2026-01-31"}}
+	asOf, _ := time.Parse(dateFormat, "2026-03-02")
+
+	//3600.00 for 30 days, then 1800.00 for 30 days at 12%. I2 is not yet due and I3 is issued after asOf
+	accrual, err := calculateLateInterest(contractDetails, invoiceList, paymentList, asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accrual.Periods) != 2 || accrual.AccruedInterest.String() != "54.00" || accrual.Principal.String() != "4600.00" {
+		t.Errorf("accrual %+v", accrual)
+	}
+}
+
+func TestAccruedInterest(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractDetails := testContract()
+	contractDetails.LateInterest = &lateInterestTerms{AnnualRate: 10, DayCount: "bad"}
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	_, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt)
+	if err == nil {
+		t.Fatal("contract saved with an unknown day count")
+	}
+
+	contractDetails.LateInterest.DayCount = DayCount_Act365
+	contractId := saveTestContract(t, stub, contractDetails)
+	advanceToInvoiced(t, stub, contractId, parties)
+	if readContract(t, stub, contractId).PaymentDueDate == "" {
+		t.Fatal("no payment due date on the invoiced contract")
+	}
+
+	//A year after the due date at 10% on 42.00
+	asOf := time.Now().AddDate(0, 0, 30+365).Format(dateFormat)
+	var accrual interestAccrual
+	json.Unmarshal(query(t, stub, "getAccruedInterest", contractId, "buyer", asOf), &accrual)
+	if accrual.AccruedInterest.String() != "4.20" {
+		t.Errorf("accrued interest %s, want 4.20", accrual.AccruedInterest)
+	}
+}
+
+func TestCheckContractDurations(t *testing.T) {
+	createDate := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name              string
+		paymentDuration   string
+		transportDuration string
+		deliveryDays      int
+		wantErr           bool
+	}{
+		{"within limits", "30", "15", 20, false},
+		{"limits are inclusive", "15", "10", 30, false},
+		{"payment too long", "31", "15", 20, true},
+		{"payment not a number", "thirty", "15", 20, true},
+		{"transport too short", "30", "9", 20, true},
+		{"delivery too soon", "30", "15", 14, true},
+		{"delivery too late", "30", "15", 31, true},
+	}
+
+	for _, test := range tests {
+		var contractDetails contract
+		contractDetails.ContractCreateDate = createDate
+		contractDetails.TradeConditions.PaymentDuration = test.paymentDuration
+		contractDetails.TradeConditions.TransportDuration = test.transportDuration
+		contractDetails.DeliveryDetails.DeliveryDate = createDate.AddDate(0, 0, test.deliveryDays).Format(time.RFC3339)
+		_, err := checkContractDurations(contractDetails, defaultConfiguration())
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v", test.name, err)
+		}
+	}
+}
+
+func TestConfigurationLimits(t *testing.T) {
+	stub := newTestStub(t)
+	today := time.Now().Format(dateFormat)
+	longPayment := testContract()
+	longPayment.TradeConditions.PaymentDuration = "60"
+	contractAsBytes, _ := json.Marshal(longPayment)
+	_, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt)
+	if err == nil {
+		t.Fatal("contract saved outside the default payment duration")
+	}
+
+	_, err = invokeErr(stub, "setConfiguration", "seller", today, `{"maxDaysPaymentDuration":90,"maxDaysTransportDuration":20,"maxDaysDeliveryDuration":30,"latestContractsCount":10}`)
+	if err == nil {
+		t.Fatal("configuration changed by a user who is not admin")
+	}
+	_, err = invokeErr(stub, "setConfiguration", "admin", today, `{"minDaysPaymentDuration":90,"maxDaysPaymentDuration":60,"maxDaysTransportDuration":20,"maxDaysDeliveryDuration":30,"latestContractsCount":10}`)
+	if err == nil {
+		t.Fatal("configuration accepted with min above max")
+	}
+	_, err = invokeErr(stub, "setConfiguration", "admin", "2000-01-01", `{"maxDaysPaymentDuration":90,"maxDaysTransportDuration":20,"maxDaysDeliveryDuration":30,"latestContractsCount":10}`)
+	if err == nil {
+		t.Fatal("back dated configuration accepted")
+	}
+
+	//The governed limit applies from its effective date
+	invoke(t, stub, "setConfiguration", "admin", today, `{"maxDaysPaymentDuration":90,"maxDaysTransportDuration":20,"maxDaysDeliveryDuration":30,"latestContractsCount":10}`)
+	contractId := saveTestContract(t, stub, longPayment)
+	if version := readContract(t, stub, contractId).ConfigVersion; version != 1 {
+		t.Errorf("contract pinned to configuration version %d, want 1", version)
+	}
+}
+
+func TestPricingSalt(t *testing.T) {
+	stub := newTestStub(t)
+	contractAsBytes, _ := json.Marshal(testContract())
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no salt", []string{string(contractAsBytes)}},
+		{"not hex", []string{string(contractAsBytes), "not a hex encoded salt at all, no"}},
+		{"too short", []string{string(contractAsBytes), "6a0f3c9e51b27d84"}},
+	}
+
+	for _, test := range tests {
+		_, err := invokeErr(stub, "saveContract", test.args...)
+		if err == nil {
+			t.Errorf("%s: contract saved", test.name)
+		}
+	}
+}
+
+func TestPricingAccess(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	buyerSignature := pricingSignature(t, stub, "buyer", parties.buyer)
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantPricing bool
+	}{
+		{"no user", []string{contractId}, false},
+		{"transporter", []string{contractId, "transporter"}, false},
+		{"buyer without signature", []string{contractId, "buyer"}, false},
+		{"seller with the buyer's signature", []string{contractId, "seller", buyerSignature}, false},
+		{"buyer with signature", []string{contractId, "buyer", buyerSignature}, true},
+	}
+	for _, test := range tests {
+		var contractDetails contract
+		json.Unmarshal(query(t, stub, "getContractDetailsByContractId", test.args...), &contractDetails)
+		hasPricing := contractDetails.TotalTradeAmount.String() == "42.00" && contractDetails.TradeDetails[0].ProductPrice == "10.50"
+		if hasPricing != test.wantPricing || contractDetails.PricingHash == "" {
+			t.Errorf("%s: pricing %v, want %v", test.name, hasPricing, test.wantPricing)
+		}
+	}
+
+	//Lists never carry pricing
+	var contractList []contract
+	json.Unmarshal(query(t, stub, "getContractDetailsByUserId", "buyer"), &contractList)
+	if len(contractList) != 1 || !contractList[0].TotalTradeAmount.IsZero() {
+		t.Errorf("contract list %+v", contractList)
+	}
+
+	_, err := stub.MockQuery("getContractPricing", []string{contractId, "seller", buyerSignature})
+	if err == nil {
+		t.Error("pricing read with another party's signature")
+	}
+	pricingAsBytes := query(t, stub, "getContractPricing", contractId, "buyer", buyerSignature)
+	var pricing contractPricing
+	json.Unmarshal(pricingAsBytes, &pricing)
+	if pricing.Salt != testPricingSalt {
+		t.Errorf("salt %s, want the client's salt", pricing.Salt)
+	}
+	var valid bool
+	json.Unmarshal(query(t, stub, "verifyContractPricing", contractId, string(pricingAsBytes)), &valid)
+	if !valid {
+		t.Error("pricing does not match the hash on the contract")
+	}
+}
+
+func TestSigningHashHidesPricing(t *testing.T) {
+	stub := newTestStub(t)
+	contractDetails := readContract(t, stub, saveTestContract(t, stub, testContract()))
+
+	hash, contractAsBytes := canonicalContractHash(contractDetails)
+	if strings.Contains(string(contractAsBytes), "10.50") || strings.Contains(string(contractAsBytes), "42.00") {
+		t.Fatalf("signed contract carries pricing: %s", contractAsBytes)
+	}
+
+	//The salted pricing hash still binds the signature to the prices
+	contractDetails.TradeDetails[0].ProductPrice = "10.40"
+	_, pricing := splitContractPricing(contractDetails, testPricingSalt)
+	contractDetails.PricingHash = hashContractPricing(pricing)
+	changedHash, _ := canonicalContractHash(contractDetails)
+	if string(changedHash) == string(hash) {
+		t.Error("signing hash unchanged by a different price")
+	}
+}
+
+func TestFindFxRate(t *testing.T) {
+	stub := newTestStub(t)
+	invoke(t, stub, "initializeUser", "rates")
+	invoke(t, stub, "assignUserRole", "admin", "rates", Role_RateProvider)
+	invoke(t, stub, "publishFxRates", "rates", "2026-10-01", `[{"fromCurrency":"EUR","toCurrency":"USD","rate":"1.25"}]`)
+	invoke(t, stub, "publishFxRates", "rates", "2026-10-03", `[{"fromCurrency":"USD","toCurrency":"GBP","rate":"0.8"}]`)
+	invoke(t, stub, "publishFxRates", "rates", "2026-10-05", `[{"fromCurrency":"EUR","toCurrency":"USD","rate":"1.2"}]`)
+
+	tests := []struct {
+		from     string
+		to       string
+		date     string
+		wantRate string
+		wantDate string
+	}{
+		{"EUR", "USD", "2026-10-01", "1.2500000000", "2026-10-01"},
+		{"EUR", "USD", "2026-10-04", "1.2500000000", "2026-10-01"},
+		{"EUR", "USD", "2026-10-09", "1.2000000000", "2026-10-05"},
+		{"USD", "EUR", "2026-10-02", "0.8000000000", "2026-10-01"},
+		{"GBP", "USD", "2026-10-04", "1.2500000000", "2026-10-03"},
+		{"EUR", "USD", "2026-09-30", "", ""},
+		{"EUR", "GBP", "2026-10-09", "", ""},
+	}
+	for _, test := range tests {
+		result, err := stub.MockQuery("getFxRate", []string{test.from, test.to, test.date})
+		if test.wantRate == "" {
+			if err == nil {
+				t.Errorf("%s/%s on %s: rate found", test.from, test.to, test.date)
+			}
+			continue
+		}
+		var rate fxRate
+		json.Unmarshal(result, &rate)
+		if err != nil || rate.Rate != test.wantRate || rate.RateDate != test.wantDate {
+			t.Errorf("%s/%s on %s: %s from %s, %v, want %s from %s", test.from, test.to, test.date, rate.Rate, rate.RateDate, err, test.wantRate, test.wantDate)
+		}
+	}
+}
+
+func TestTradeTotals(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "initializeUser", "rates")
+	invoke(t, stub, "assignUserRole", "admin", "rates", Role_RateProvider)
+	invoke(t, stub, "publishFxRates", "rates", "2026-10-01", `[{"fromCurrency":"EUR","toCurrency":"USD","rate":"1.25"}]`)
+	saveTestContract(t, stub, testContract())
+	signature := pricingSignature(t, stub, "buyer", parties.buyer)
+
+	//The 2026-10-01 rate applies to a later reporting date without its own rate
+	var totals tradeTotals
+	json.Unmarshal(query(t, stub, "getTradeTotals", "buyer", "EUR", "2026-10-10", signature), &totals)
+	if totals.Total.String() != "33.60" || len(totals.RatesUsed) != 1 || totals.RatesUsed[0].RateDate != "2026-10-01" {
+		t.Errorf("totals %+v", totals)
+	}
+	var staticDetails staticData
+	json.Unmarshal(query(t, stub, "getStaticDetailsByUserId", "buyer", "USD", "2026-10-10", signature), &staticDetails)
+	if staticDetails.TradeTotals == nil || staticDetails.TradeTotals.Total.String() != "42.00" {
+		t.Errorf("dashboard totals %+v", staticDetails.TradeTotals)
+	}
+
+	_, err := stub.MockQuery("getTradeTotals", []string{"buyer", "EUR", "2026-10-10"})
+	if err == nil {
+		t.Error("trade totals read without a signature")
+	}
+	_, err = stub.MockQuery("getTradeTotals", []string{"seller", "EUR", "2026-10-10", signature})
+	if err == nil {
+		t.Error("trade totals read with another user's signature")
+	}
+}
+
+func TestInvoices(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToDelivered(t, stub, contractId, parties)
+
+	_, err := invokeErr(stub, "UpdateContractStatus", "seller", contractId)
+	if err == nil {
+		t.Fatal("invoice status reached without an invoice")
+	}
+	invoiceNumber := string(invoke(t, stub, "createInvoice", "seller", contractId, `{"lines":[{"lineNumber":1,"quantity":"3","taxRate":10}]}`))
+	creditNoteNumber := string(invoke(t, stub, "createInvoice", "seller", contractId, `{"invoiceType":"CreditNote","originalInvoiceNumber":"`+invoiceNumber+`","lines":[{"lineNumber":1,"quantity":"1"}]}`))
+	if invoiceNumber != "INV-000001" || creditNoteNumber != "CN-000001" {
+		t.Errorf("numbers %s and %s, want INV-000001 and CN-000001", invoiceNumber, creditNoteNumber)
+	}
+	_, err = invokeErr(stub, "createInvoice", "seller", contractId, `{"lines":[{"lineNumber":1,"quantity":"3"}]}`)
+	if err == nil {
+		t.Fatal("invoiced above the delivered quantity")
+	}
+	invoke(t, stub, "createInvoice", "seller", contractId, `{}`)
+
+	//Three at 10.50 plus 10% tax, then the remaining two after the credit note
+	var invoiceList []invoice
+	json.Unmarshal(query(t, stub, "getContractInvoices", contractId, "buyer"), &invoiceList)
+	if len(invoiceList) != 3 || invoiceList[0].TotalAmount.String() != "34.65" || invoiceList[2].Lines[0].Quantity != "2" {
+		t.Fatalf("invoices %+v", invoiceList)
+	}
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+}
+
+func TestDeliveredQuantities(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToShipped(t, stub, contractId, parties)
+
+	tests := []struct {
+		name       string
+		quantities string
+	}{
+		{"above contracted", `[{"lineNumber":1,"quantity":"5"}]`},
+		{"negative", `[{"lineNumber":1,"quantity":"-1"}]`},
+		{"unknown line", `[{"lineNumber":2,"quantity":"1"}]`},
+		{"not json", `three`},
+	}
+	for _, test := range tests {
+		_, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId, test.quantities)
+		if err == nil {
+			t.Fatalf("%s: delivery accepted", test.name)
+		}
+	}
+
+	//The short delivery is on the contract before the seller can invoice
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId, `[{"lineNumber":1,"quantity":"3"}]`)
+	contractDetails := readContract(t, stub, contractId)
+	if contractDetails.ContractStatus != Shipment_Delivered || contractDetails.TradeDetails[0].DeliveredQuantity != "3" {
+		t.Fatalf("status %s delivered %s", contractDetails.ContractStatus, contractDetails.TradeDetails[0].DeliveredQuantity)
+	}
+	invoiceNumber := string(invoke(t, stub, "createInvoice", "seller", contractId, `{}`))
+	invoiceDetails, _ := getInvoiceDetails(stub, "seller", invoiceNumber)
+	if invoiceDetails.TotalAmount.String() != "31.50" {
+		t.Errorf("invoice total %s, want 31.50", invoiceDetails.TotalAmount)
+	}
+
+	_, err := invokeErr(stub, "recordDeliveredQuantities", "buyer", contractId, `[{"lineNumber":1,"quantity":"2"}]`)
+	if err == nil {
+		t.Error("delivered quantity lowered below the invoiced quantity")
+	}
+}
+
+func TestFullDeliveryByDefault(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToDelivered(t, stub, contractId, parties)
+
+	if delivered := readContract(t, stub, contractId).TradeDetails[0].DeliveredQuantity; delivered != "4" {
+		t.Errorf("delivered %s, want the contracted 4", delivered)
+	}
+}
+
+func TestGetContractRoles(t *testing.T) {
+	contractDetails := testContract()
+	contractDetails.DeliveryDetails.TransporterDetails = contractDetails.SellerDetails.Seller
+	contractDetails.DeliveryDetails.TransportLegs = []transportLeg{{Transporter: contractDetails.SellerDetails.Seller}, {Transporter: user{UserId: "carrier"}}}
+
+	tests := []struct {
+		userId string
+		want   []string
+	}{
+		{"seller", []string{Party_Seller, Party_Transporter}},
+		{"buyerbank", []string{Party_BuyerBank}},
+		{"carrier", []string{Party_LegTransporter}},
+		{"stranger", nil},
+	}
+	for _, test := range tests {
+		roles := getContractRoles(contractDetails, test.userId)
+		if strings.Join(roles, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: roles %v, want %v", test.userId, roles, test.want)
+		}
+	}
+}
+
+func TestNotificationsAcrossRoles(t *testing.T) {
+	stub := newTestStub(t)
+	saveTestContract(t, stub, testContract())
+
+	//The seller of the first contract buys on the second
+	time.Sleep(time.Second)
+	swapped := testContract()
+	swapped.SellerDetails.Seller, swapped.BuyerDetails.Buyer = swapped.BuyerDetails.Buyer, swapped.SellerDetails.Seller
+	saveTestContract(t, stub, swapped)
+
+	for _, userId := range []string{"seller", "buyer"} {
+		var pendingActions []pendingAction
+		json.Unmarshal(query(t, stub, "getNotificationStatus", userId), &pendingActions)
+		if len(pendingActions) != 1 || pendingActions[0].Role != Party_Buyer || pendingActions[0].Contract.BuyerDetails.Buyer.UserId != userId {
+			t.Errorf("%s: pending actions %+v", userId, pendingActions)
+		}
+		json.Unmarshal(query(t, stub, "getNotificationStatus", userId, Party_Seller), &pendingActions)
+		if len(pendingActions) != 0 {
+			t.Errorf("%s: pending as seller %+v", userId, pendingActions)
+		}
+
+		var counts notificationCountStatus
+		json.Unmarshal(query(t, stub, "getNotificationCountStatus", userId), &counts)
+		if len(counts.ByRole) != 1 || counts.ByRole[Party_Buyer].ContractCount != 1 {
+			t.Errorf("%s: counts %+v", userId, counts)
+		}
+
+		var staticDetails staticData
+		json.Unmarshal(query(t, stub, "getStaticDetailsByUserId", userId), &staticDetails)
+		if staticDetails.TotalContracts != 2 || staticDetails.NotificationByRole[Party_Buyer] != 1 || staticDetails.NotificationByRole[Party_Seller] != 0 {
+			t.Errorf("%s: dashboard %d contracts, notifications %v", userId, staticDetails.TotalContracts, staticDetails.NotificationByRole)
+		}
+	}
+}
+
+func TestIssueLetterOfCredit(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+
+	if _, err := invokeErr(stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId)); err == nil {
+		t.Fatal("letter of credit issued before the contract was accepted")
+	}
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyerbank", contractId); err == nil {
+		t.Fatal("LC Created without a letter of credit")
+	}
+
+	tests := []struct {
+		name   string
+		userId string
+		lc     string
+	}{
+		{"wrong bank", "sellerbank", testLetterOfCredit("LC-1")},
+		{"wrong currency", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"currency":"USD"`, `"currency":"EUR"`, 1)},
+		{"amount outside tolerance", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"42.00"`, `"30.00"`, 1)},
+		{"tolerance over 100", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"tolerancePercentage":5`, `"tolerancePercentage":150`, 1)},
+		{"expired", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"expiryDate":"2099-01-01"`, `"expiryDate":"2000-01-01"`, 1)},
+		{"shipment after expiry", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"latestShipmentDate":"2098-12-01"`, `"latestShipmentDate":"2099-02-01"`, 1)},
+		{"no expiry place", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `"expiryPlace":"Hamburg"`, `"expiryPlace":""`, 1)},
+		{"no documents", "buyerbank", strings.Replace(testLetterOfCredit("LC-1"), `["Invoice","Bill of Lading"]`, `[]`, 1)},
+	}
+	for _, test := range tests {
+		if _, err := invokeErr(stub, "issueLetterOfCredit", test.userId, contractId, test.lc); err == nil {
+			t.Errorf("%s: letter of credit issued", test.name)
+		}
+	}
+
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId))
+	if _, err := stub.MockQuery("getLetterOfCredit", []string{"LC-" + contractId, "transporter"}); err == nil {
+		t.Error("transporter read the letter of credit")
+	}
+	var lcDetails letterOfCredit
+	json.Unmarshal(query(t, stub, "getLetterOfCredit", "LC-"+contractId, "seller"), &lcDetails)
+	if lcDetails.Status != LC_Status_Issued || lcDetails.Applicant != "buyer" || lcDetails.Beneficiary != "seller" || lcDetails.AdvisingBank != "sellerbank" {
+		t.Errorf("letter of credit %+v", lcDetails)
+	}
+	if readContract(t, stub, contractId).LCNumber != "LC-"+contractId {
+		t.Error("contract does not reference the letter of credit")
+	}
+}
+
+func TestLetterOfCreditAmendment(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	lcNumber := "LC-" + contractId
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit(lcNumber))
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+
+	if _, err := invokeErr(stub, "amendLetterOfCredit", "sellerbank", lcNumber, `{"tolerancePercentage":10}`); err == nil {
+		t.Error("advising bank amended the letter of credit")
+	}
+	if _, err := invokeErr(stub, "amendLetterOfCredit", "buyerbank", lcNumber, `{"amount":{"amount":"30.00","currency":"USD"}}`); err == nil {
+		t.Error("amendment left the contract amount outside the letter of credit")
+	}
+	invoke(t, stub, "amendLetterOfCredit", "buyerbank", lcNumber, `{"tolerancePercentage":10}`)
+	if _, err := invokeErr(stub, "amendLetterOfCredit", "buyerbank", lcNumber, `{"tolerancePercentage":8}`); err == nil {
+		t.Error("second amendment while one is pending")
+	}
+
+	//The seller bank cannot approve until it has answered the amendment
+	var signingHash contractSignature
+	json.Unmarshal(query(t, stub, "getContractSigningHash", contractId), &signingHash)
+	if _, err := invokeErr(stub, "UpdateContractStatus", "sellerbank", contractId, parties.sellerBank.sign(signingHash.ContractHash)); err == nil {
+		t.Fatal("LC approved with a pending amendment")
+	}
+	if _, err := invokeErr(stub, "respondLCAmendment", "buyerbank", lcNumber, "1", Amendment_Accepted); err == nil {
+		t.Error("issuing bank answered its own amendment")
+	}
+	invoke(t, stub, "respondLCAmendment", "sellerbank", lcNumber, "1", Amendment_Accepted)
+	if _, err := invokeErr(stub, "respondLCAmendment", "sellerbank", lcNumber, "1", Amendment_Rejected); err == nil {
+		t.Error("amendment answered twice")
+	}
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+
+	var lcDetails letterOfCredit
+	json.Unmarshal(query(t, stub, "getLetterOfCredit", lcNumber, "buyer"), &lcDetails)
+	if lcDetails.TolerancePercentage != 10 || lcDetails.Status != LC_Status_Advised || lcDetails.Amendments[0].Status != Amendment_Accepted {
+		t.Errorf("letter of credit %+v", lcDetails)
+	}
+	if status := readContract(t, stub, contractId).ContractStatus; status != LC_Approved {
+		t.Errorf("contract status %s", status)
+	}
+}
+
+func TestReissueLetterOfCredit(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "setCreditLimit", "buyerbank", "buyer", "USD", "50")
+	contractId := saveTestContract(t, stub, testContract())
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId))
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+
+	if _, err := invokeErr(stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId+"-2")); err == nil {
+		t.Fatal("second letter of credit issued while the first is live")
+	}
+	if _, err := invokeErr(stub, "expireLetterOfCredit", "LC-"+contractId); err == nil {
+		t.Fatal("letter of credit expired before its expiry date")
+	}
+
+	stub.MockTransactionStart("expire")
+	lcDetails, _ := getLetterOfCreditDetails(stub, "LC-"+contractId)
+	lcDetails.ExpiryDate = "2000-01-01"
+	updateLetterOfCreditDetails(stub, lcDetails)
+	stub.MockTransactionEnd("expire")
+	invoke(t, stub, "expireLetterOfCredit", "LC-"+contractId)
+	json.Unmarshal(query(t, stub, "getLetterOfCredit", "LC-"+contractId, "buyer"), &lcDetails)
+	if lcDetails.Status != LC_Status_Expired {
+		t.Fatalf("letter of credit status %s", lcDetails.Status)
+	}
+
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId+"-2"))
+	var utilisation []creditUtilisation
+	json.Unmarshal(query(t, stub, "getCreditUtilisation", "buyerbank"), &utilisation)
+	if len(utilisation) != 1 || utilisation[0].Utilised.String() != "44.10" {
+		t.Errorf("credit utilisation %+v", utilisation)
+	}
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+	if contractDetails := readContract(t, stub, contractId); contractDetails.ContractStatus != LC_Approved || contractDetails.LCNumber != "LC-"+contractId+"-2" {
+		t.Errorf("contract %s with %s", contractDetails.ContractStatus, contractDetails.LCNumber)
+	}
+}
+
+func TestPaymentSchedule(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+
+	contractDetails := testContract()
+	contractDetails.PaymentSchedule = []milestone{{MilestoneId: "deposit", TriggerStatus: Contract_Created, Percentage: 30}}
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	if _, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt); err == nil {
+		t.Fatal("payment schedule that does not add up to 100 percent saved")
+	}
+
+	contractDetails.PaymentSchedule = []milestone{
+		{MilestoneId: "deposit", TriggerStatus: Contract_Created, Percentage: 33.33},
+		{MilestoneId: "balance", TriggerStatus: Shipment_Delivered, Percentage: 66.67, DueDays: 5},
+	}
+	contractId := saveTestContract(t, stub, contractDetails)
+
+	var schedule []milestoneStatus
+	json.Unmarshal(query(t, stub, "getPaymentSchedule", contractId, "seller"), &schedule)
+	if len(schedule) != 2 || schedule[0].Status != Milestone_Due || schedule[0].AmountDue.String() != "14.00" || schedule[1].Status != Milestone_Scheduled {
+		t.Fatalf("payment schedule %+v", schedule)
+	}
+
+	invoke(t, stub, "recordMilestonePayment", "buyer", contractId, "deposit", "4.00")
+	if _, err := invokeErr(stub, "recordMilestonePayment", "buyer", contractId, "deposit", "10.01"); err == nil {
+		t.Error("milestone overpaid")
+	}
+	var dashboard staticData
+	json.Unmarshal(query(t, stub, "getStaticDetailsByUserId", "buyer"), &dashboard)
+	if dashboard.PaymentStatus.DueMilestones != 1 || len(dashboard.PaymentStatus.Outstanding) != 1 || dashboard.PaymentStatus.Outstanding[0].String() != "10.00" {
+		t.Errorf("payment status %+v", dashboard.PaymentStatus)
+	}
+
+	//The balance falls due once the buyer takes delivery
+	advanceToDelivered(t, stub, contractId, parties)
+	json.Unmarshal(query(t, stub, "getPaymentSchedule", contractId, "buyer"), &schedule)
+	if schedule[0].Status != Milestone_PartiallyPaid || schedule[1].Status != Milestone_Due || schedule[1].AmountDue.String() != "28.00" {
+		t.Fatalf("payment schedule %+v", schedule)
+	}
+	invoke(t, stub, "recordMilestonePayment", "buyerbank", contractId, "balance", "28.00")
+	json.Unmarshal(query(t, stub, "getPaymentSchedule", contractId, "buyer"), &schedule)
+	if schedule[1].Status != Milestone_Paid {
+		t.Errorf("balance milestone %s", schedule[1].Status)
+	}
+}
+
+func TestZeroAmountMilestonePaid(t *testing.T) {
+	stub := newTestStub(t)
+	contractDetails := contract{ContractId: "zero", ContractStatus: Contract_Created, TotalTradeAmount: money{Minor: 0, Currency: "USD"}}
+	contractDetails.TradeConditions.Currency = "USD"
+	contractDetails.PaymentSchedule = []milestone{{MilestoneId: "full", TriggerStatus: Contract_Created, Percentage: 100}}
+
+	stub.MockTransactionStart("milestones")
+	triggerMilestones(stub, contractDetails, nil)
+	stub.MockTransactionEnd("milestones")
+	schedule := getMilestoneStatusList(stub, "zero")
+	if len(schedule) != 1 || schedule[0].Status != Milestone_Paid {
+		t.Errorf("payment schedule %+v", schedule)
+	}
+}
+
+func TestReceivableFactoring(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	for _, financierId := range []string{"financier1", "financier2"} {
+		invoke(t, stub, "initializeUser", financierId)
+		invoke(t, stub, "assignUserRole", "admin", financierId, Role_Financier)
+	}
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToDelivered(t, stub, contractId, parties)
+	invoiceNumber := string(invoke(t, stub, "createInvoice", "seller", contractId, `{}`))
+
+	if _, err := invokeErr(stub, "offerReceivable", "seller", contractId, invoiceNumber); err == nil {
+		t.Fatal("receivable offered before the invoice was sent")
+	}
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	if _, err := invokeErr(stub, "offerReceivable", "buyer", contractId, invoiceNumber); err == nil {
+		t.Error("buyer offered the seller's receivable")
+	}
+	invoke(t, stub, "offerReceivable", "seller", contractId, invoiceNumber)
+	if _, err := invokeErr(stub, "offerReceivable", "seller", contractId, invoiceNumber); err == nil {
+		t.Error("receivable offered twice")
+	}
+
+	if _, err := invokeErr(stub, "bidReceivable", "transporter", contractId, invoiceNumber, "2"); err == nil {
+		t.Error("bid from a user without the financier role")
+	}
+	invoke(t, stub, "bidReceivable", "financier1", contractId, invoiceNumber, "2")
+	invoke(t, stub, "bidReceivable", "financier2", contractId, invoiceNumber, "1.5")
+
+	//Financiers only see their own bid, the seller sees all of them
+	var offers []receivableOffer
+	json.Unmarshal(query(t, stub, "getOpenReceivables", "financier1"), &offers)
+	if len(offers) != 1 || offers[0].InvoiceNumber != invoiceNumber || len(offers[0].Bids) != 1 || offers[0].Bids[0].AdvanceAmount.String() != "41.16" {
+		t.Fatalf("open receivables %+v", offers)
+	}
+	var offer receivableOffer
+	json.Unmarshal(query(t, stub, "getReceivableOffer", contractId, invoiceNumber, "seller"), &offer)
+	if len(offer.Bids) != 2 {
+		t.Errorf("receivable offer %+v", offer)
+	}
+
+	invoke(t, stub, "acceptReceivableBid", "seller", contractId, invoiceNumber, "financier2")
+	json.Unmarshal(query(t, stub, "getOpenReceivables", "financier1"), &offers)
+	if len(offers) != 0 {
+		t.Errorf("open receivables after acceptance %+v", offers)
+	}
+
+	//Payment of the assigned invoice goes to the financier
+	if _, err := invokeErr(stub, "UpdateContractStatus", "sellerbank", contractId, `{"bankReference":"R0","valueDate":"2026-10-19"}`); err == nil {
+		t.Error("payment of a factored invoice without an invoice number")
+	}
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, `{"invoiceNumber":"`+invoiceNumber+`","bankReference":"R1","valueDate":"2026-10-19"}`)
+	var payments []paymentRecord
+	json.Unmarshal(query(t, stub, "getContractPayments", contractId, "seller"), &payments)
+	if len(payments) != 1 || payments[0].PayeeId != "financier2" || payments[0].InvoiceNumber != invoiceNumber || payments[0].Amount.String() != "42.00" {
+		t.Errorf("payments %+v", payments)
+	}
+}
+
+func TestFactoringPerInvoice(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "initializeUser", "financier1")
+	invoke(t, stub, "assignUserRole", "admin", "financier1", Role_Financier)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToDelivered(t, stub, contractId, parties)
+	firstInvoice := string(invoke(t, stub, "createInvoice", "seller", contractId, `{"lines":[{"lineNumber":1,"quantity":"1"}]}`))
+	secondInvoice := string(invoke(t, stub, "createInvoice", "seller", contractId, `{}`))
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+
+	invoke(t, stub, "offerReceivable", "seller", contractId, firstInvoice)
+	invoke(t, stub, "offerReceivable", "seller", contractId, secondInvoice)
+	invoke(t, stub, "bidReceivable", "financier1", contractId, secondInvoice, "2")
+	invoke(t, stub, "acceptReceivableBid", "seller", contractId, secondInvoice, "financier1")
+	var offers []receivableOffer
+	json.Unmarshal(query(t, stub, "getOpenReceivables", "financier1"), &offers)
+	if len(offers) != 1 || offers[0].InvoiceNumber != firstInvoice {
+		t.Fatalf("open receivables %+v", offers)
+	}
+
+	//Only the assigned invoice is paid to the financier
+	invoke(t, stub, "UpdateContractStatus", "sellerbank", contractId, `{"invoiceNumber":"`+firstInvoice+`","bankReference":"R1","valueDate":"2026-10-19"}`)
+	var payments []paymentRecord
+	json.Unmarshal(query(t, stub, "getContractPayments", contractId, "seller"), &payments)
+	if len(payments) != 1 || payments[0].PayeeId != "seller" {
+		t.Errorf("payments %+v", payments)
+	}
+}
+
+func TestFinancingRegistry(t *testing.T) {
+	stub := newTestStub(t)
+	for _, financierId := range []string{"financier1", "financier2"} {
+		invoke(t, stub, "initializeUser", financierId)
+		invoke(t, stub, "assignUserRole", "admin", financierId, Role_Financier)
+	}
+
+	//Identifiers are normalised before hashing so formatting differences do not hide a duplicate
+	documentHash := string(query(t, stub, "getDocumentHash", "financier1", "invoice", "seller", "inv-000001"))
+	if documentHash != string(query(t, stub, "getDocumentHash", "financier2", "invoice", "SELLER", "INV 000001")) {
+		t.Fatal("document hash depends on identifier formatting")
+	}
+	if _, err := stub.MockQuery("getDocumentHash", []string{"seller", "invoice", "seller", "inv-000001"}); err == nil {
+		t.Error("document hash computed for a user without the financier role")
+	}
+	if _, err := invokeErr(stub, "registerFinancing", "seller", "invoice", documentHash); err == nil {
+		t.Error("financing registered by a user without the financier role")
+	}
+	if _, err := invokeErr(stub, "registerFinancing", "financier1", "invoice", "not-a-hash"); err == nil {
+		t.Error("financing registered without a document hash")
+	}
+
+	var check financingCheck
+	json.Unmarshal(invoke(t, stub, "registerFinancing", "financier1", "invoice", documentHash), &check)
+	if check.Status != Financing_Active {
+		t.Fatalf("first financing %+v", check)
+	}
+	json.Unmarshal(invoke(t, stub, "registerFinancing", "financier2", "invoice", documentHash), &check)
+	if check.Status != Financing_Duplicate {
+		t.Fatalf("second financing %+v", check)
+	}
+
+	//The owner sees the duplicate attempt, the other financier only learns the document is financed
+	var registration financingRegistration
+	json.Unmarshal(query(t, stub, "getFinancingStatus", "financier1", documentHash), &registration)
+	if len(registration.DuplicateAttempts) != 1 {
+		t.Errorf("registration %+v", registration)
+	}
+	json.Unmarshal(query(t, stub, "getFinancingStatus", "financier2", documentHash), &check)
+	if check.Status != Financing_Active || check.Owned {
+		t.Errorf("status for another financier %+v", check)
+	}
+
+	if _, err := invokeErr(stub, "releaseFinancing", "financier2", documentHash); err == nil {
+		t.Error("financing released by another financier")
+	}
+	invoke(t, stub, "releaseFinancing", "financier1", documentHash)
+	json.Unmarshal(invoke(t, stub, "registerFinancing", "financier2", "invoice", documentHash), &check)
+	if check.Status != Financing_Active {
+		t.Errorf("financing after release %+v", check)
+	}
+}
+
+func TestFactoringChecksRegistry(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	for _, financierId := range []string{"financier1", "financier2"} {
+		invoke(t, stub, "initializeUser", financierId)
+		invoke(t, stub, "assignUserRole", "admin", financierId, Role_Financier)
+	}
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToDelivered(t, stub, contractId, parties)
+	invoiceNumber := string(invoke(t, stub, "createInvoice", "seller", contractId, `{}`))
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "offerReceivable", "seller", contractId, invoiceNumber)
+	invoke(t, stub, "bidReceivable", "financier2", contractId, invoiceNumber, "1.5")
+
+	//financier1 already financed the same invoice elsewhere, so the assignment is refused and the attempt recorded
+	documentHash := string(query(t, stub, "getDocumentHash", "financier1", "invoice", "seller", invoiceNumber))
+	invoke(t, stub, "registerFinancing", "financier1", "invoice", documentHash)
+	var check financingCheck
+	json.Unmarshal(invoke(t, stub, "acceptReceivableBid", "seller", contractId, invoiceNumber, "financier2"), &check)
+	if check.Status != Financing_Duplicate {
+		t.Fatalf("assignment of a financed invoice %+v", check)
+	}
+	var registration financingRegistration
+	json.Unmarshal(query(t, stub, "getFinancingStatus", "financier1", documentHash), &registration)
+	if len(registration.DuplicateAttempts) != 1 {
+		t.Errorf("registration %+v", registration)
+	}
+	var offers []receivableOffer
+	json.Unmarshal(query(t, stub, "getOpenReceivables", "financier2"), &offers)
+	if len(offers) != 1 {
+		t.Errorf("offer closed by a refused assignment %+v", offers)
+	}
+
+	invoke(t, stub, "releaseFinancing", "financier1", documentHash)
+	invoke(t, stub, "acceptReceivableBid", "seller", contractId, invoiceNumber, "financier2")
+	json.Unmarshal(query(t, stub, "getFinancingStatus", "financier1", documentHash), &check)
+	if check.Status != Financing_Active || check.Owned {
+		t.Errorf("assigned invoice not registered to the financier %+v", check)
+	}
+}
+
+func TestContractTaxes(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	if _, err := invokeErr(stub, "setTaxRates", "seller", `{"country":"DE"}`); err == nil {
+		t.Fatal("tax rates set by a user who is not an admin")
+	}
+	invoke(t, stub, "setTaxRates", "admin", `{"country":"DE","taxRates":[{"taxCategory":"standard","rate":19}],"dutyRates":[{"hsCode":"","rate":2},{"hsCode":"8471","rate":0},{"hsCode":"8471.30","rate":4}]}`)
+
+	contractDetails := testContract()
+	contractDetails.TradeDetails[0].HSCode = "8471.30.01"
+	contractDetails.TradeDetails[0].TaxCategory = "standard"
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	if _, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt); err == nil {
+		t.Fatal("taxed contract saved without a destination country")
+	}
+	contractDetails.DeliveryDetails.DestinationCountry = "DE"
+	contractId := saveTestContract(t, stub, contractDetails)
+
+	//The longest matching HS code prefix sets the duty rate
+	contractDetails = readContract(t, stub, contractId)
+	if contractDetails.Taxes == nil || contractDetails.Taxes.TaxAmount.String() != "7.98" || contractDetails.Taxes.DutyAmount.String() != "1.68" {
+		t.Fatalf("taxes %+v", contractDetails.Taxes)
+	}
+
+	//Taxes are part of the hashed pricing
+	pricing, _ := getContractPricingDetails(stub, contractId)
+	pricingAsBytes, _ := json.Marshal(pricing)
+	var verified bool
+	json.Unmarshal(query(t, stub, "verifyContractPricing", contractId, string(pricingAsBytes)), &verified)
+	if !verified {
+		t.Error("pricing with taxes does not match the contract hash")
+	}
+
+	advanceToDelivered(t, stub, contractId, parties)
+	invoke(t, stub, "createInvoice", "seller", contractId, `{}`)
+	var invoices []invoice
+	json.Unmarshal(query(t, stub, "getContractInvoices", contractId, "buyer"), &invoices)
+	if len(invoices) != 1 || invoices[0].TaxAmount.String() != "7.98" || invoices[0].DutyAmount.String() != "1.68" || invoices[0].TotalAmount.String() != "49.98" {
+		t.Errorf("invoices %+v", invoices)
+	}
+}
+
+func TestGuarantees(t *testing.T) {
+	stub := newTestStub(t)
+	newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	performanceGuarantee := `{"guaranteeNumber":"G1","guaranteeType":"performance","amount":{"amount":"10","currency":"USD"},"expiryDate":"2099-01-01"}`
+
+	//A performance guarantee covers the seller, so only the seller's bank issues it
+	if _, err := invokeErr(stub, "issueGuarantee", "buyerbank", contractId, performanceGuarantee); err == nil {
+		t.Fatal("performance guarantee issued by the buyer's bank")
+	}
+	invoke(t, stub, "issueGuarantee", "sellerbank", contractId, performanceGuarantee)
+	invoke(t, stub, "amendGuarantee", "sellerbank", "G1", `{"amount":{"amount":"20","currency":"USD"}}`)
+	if _, err := invokeErr(stub, "respondGuaranteeAmendment", "seller", "G1", "1", Amendment_Accepted); err == nil {
+		t.Error("amendment accepted by someone other than the beneficiary")
+	}
+	invoke(t, stub, "respondGuaranteeAmendment", "buyer", "G1", "1", Amendment_Accepted)
+
+	if _, err := invokeErr(stub, "claimGuarantee", "buyer", "G1", "25", "late shipment"); err == nil {
+		t.Error("claim above the guarantee amount")
+	}
+	invoke(t, stub, "claimGuarantee", "buyer", "G1", "15", "seller failed to ship")
+	invoke(t, stub, "claimGuarantee", "buyer", "G1", "5", "remaining damages")
+	var guarantees []guarantee
+	json.Unmarshal(query(t, stub, "getContractGuarantees", contractId, "seller"), &guarantees)
+	if len(guarantees) != 1 || guarantees[0].Status != Guarantee_Status_Exhausted || guarantees[0].ClaimedAmount.String() != "20.00" {
+		t.Fatalf("guarantees %+v", guarantees)
+	}
+	if balance := settlementBalanceOf(t, stub, "sellerbank"); balance != "980.00" {
+		t.Errorf("guarantor balance after claims %s", balance)
+	}
+
+	//A standby cannot be claimed before it is valid or after the beneficiary releases it
+	invoke(t, stub, "issueGuarantee", "buyerbank", contractId, `{"guaranteeNumber":"S1","guaranteeType":"standbyLC","amount":{"amount":"10","currency":"USD"},"validFrom":"2098-01-01","expiryDate":"2099-01-01"}`)
+	if _, err := invokeErr(stub, "claimGuarantee", "seller", "S1", "1", "unpaid"); err == nil {
+		t.Error("claim before the standby is valid")
+	}
+	invoke(t, stub, "releaseGuarantee", "seller", "S1")
+	if _, err := invokeErr(stub, "claimGuarantee", "seller", "S1", "1", "unpaid"); err == nil {
+		t.Error("claim on a released standby")
+	}
+}
+
+func TestStandbyInsteadOfLetterOfCredit(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractDetails := testContract()
+	contractDetails.SecuredByStandby = true
+	contractId := saveTestContract(t, stub, contractDetails)
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyerbank", contractId); err == nil {
+		t.Fatal("LC Created without a standby")
+	}
+	invoke(t, stub, "issueGuarantee", "buyerbank", contractId, `{"guaranteeNumber":"SB1","guaranteeType":"standbyLC","amount":{"amount":"30","currency":"USD"},"expiryDate":"2099-01-01"}`)
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyerbank", contractId); err == nil {
+		t.Fatal("LC Created with a standby below the contract amount")
+	}
+	invoke(t, stub, "issueGuarantee", "buyerbank", contractId, `{"guaranteeNumber":"SB2","guaranteeType":"standbyLC","amount":{"amount":"42","currency":"USD"},"expiryDate":"2099-01-01"}`)
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+	if contractDetails = readContract(t, stub, contractId); contractDetails.ContractStatus != LC_Approved || contractDetails.LCNumber != "" {
+		t.Errorf("contract %s with letter of credit %q", contractDetails.ContractStatus, contractDetails.LCNumber)
+	}
+}
+
+// testTrackingEvent is a tracking event at the location the given number of hours ago
+func testTrackingEvent(eventType string, location string, hoursAgo int) string {
+	timestamp := time.Now().Add(-time.Duration(hoursAgo) * time.Hour).Format(time.RFC3339)
+	return `{"eventType":"` + eventType + `","location":"` + location + `","timestamp":"` + timestamp + `"}`
+}
+
+func TestShipmentTracking(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+
+	if _, err := invokeErr(stub, "postTrackingEvent", "transporter", contractId, testTrackingEvent("pickedUp", "Pune", 5)); err == nil {
+		t.Fatal("tracking event posted before the shipment was ready")
+	}
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "postTrackingEvent", "transporter", contractId, testTrackingEvent("pickedUp", "Pune", 5))
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	invoke(t, stub, "postTrackingEvent", "transporter", contractId, testTrackingEvent("arrived", "Hamburg", 1))
+	invoke(t, stub, "postTrackingEvent", "transporter", contractId, testTrackingEvent("departed", "Mumbai", 3))
+	if _, err := invokeErr(stub, "postTrackingEvent", "seller", contractId, testTrackingEvent("arrived", "Hamburg", 1)); err == nil {
+		t.Error("tracking event posted by someone other than the transporter")
+	}
+
+	//The timeline is ordered by event time, not by the order events were posted
+	var timeline []trackingEvent
+	json.Unmarshal(query(t, stub, "getShipmentTimeline", contractId, "buyer"), &timeline)
+	if len(timeline) != 3 || timeline[0].Location != "Pune" || timeline[1].Location != "Mumbai" || timeline[2].Location != "Hamburg" {
+		t.Fatalf("timeline %+v", timeline)
+	}
+	if _, err := stub.MockQuery("getShipmentTimeline", []string{contractId, "outsider"}); err == nil {
+		t.Error("timeline read by someone outside the contract")
+	}
+
+	var contracts []contract
+	json.Unmarshal(query(t, stub, "getContractDetailsByUserId", "buyer"), &contracts)
+	if len(contracts) != 1 || contracts[0].CurrentLocation != "Hamburg" {
+		t.Errorf("contract list %+v", contracts)
+	}
+}
+
+func TestBillOfLading(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractId := saveTestContract(t, stub, testContract())
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+
+	if _, err := invokeErr(stub, "issueBillOfLading", "seller", contractId, `{"blNumber":"BL1","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`); err == nil {
+		t.Fatal("bill of lading issued by someone other than the transporter")
+	}
+	invoke(t, stub, "issueBillOfLading", "transporter", contractId, `{"blNumber":"BL1","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`)
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+	}{
+		{"endorsed by a bank that does not hold it", "endorseBillOfLading", []string{"sellerbank", "BL1", "buyerbank"}},
+		{"surrendered by the shipper", "surrenderBillOfLading", []string{"seller", "BL1"}},
+		{"endorsed straight to the consignee", "endorseBillOfLading", []string{"seller", "BL1", "buyer"}},
+	}
+	for _, test := range tests {
+		if _, err := invokeErr(stub, test.function, test.args...); err == nil {
+			t.Errorf("bill of lading %s", test.name)
+		}
+	}
+
+	invoke(t, stub, "endorseBillOfLading", "seller", "BL1", "buyerbank")
+	if _, err := invokeErr(stub, "surrenderBillOfLading", "buyerbank", "BL1"); err == nil {
+		t.Error("bill of lading surrendered by a bank")
+	}
+	if _, err := invokeErr(stub, "endorseBillOfLading", "buyerbank", "BL1", "sellerbank"); err == nil {
+		t.Error("bill of lading endorsed back along the chain")
+	}
+
+	//Delivery needs the bill of lading surrendered by the consignee
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId); err == nil {
+		t.Fatal("delivered before the bill of lading was surrendered")
+	}
+	invoke(t, stub, "endorseBillOfLading", "buyerbank", "BL1", "buyer")
+	invoke(t, stub, "surrenderBillOfLading", "buyer", "BL1")
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId)
+
+	var blDetails billOfLading
+	json.Unmarshal(query(t, stub, "getBillOfLading", "BL1", "transporter"), &blDetails)
+	if len(blDetails.Endorsements) != 2 || blDetails.Status != BL_Status_Surrendered {
+		t.Errorf("bill of lading %+v", blDetails)
+	}
+	if !readContract(t, stub, contractId).IsBillOfLedingAttached {
+		t.Error("contract does not show the bill of lading attached")
+	}
+}
+
+func TestIncoterms(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractDetails := testContract()
+	contractDetails.DeliveryDetails.Incoterm = "XYZ"
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	if _, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt); err == nil {
+		t.Fatal("contract saved with an unknown incoterm")
+	}
+
+	contractDetails.DeliveryDetails.Incoterm = "cif"
+	contractId := saveTestContract(t, stub, contractDetails)
+	contractDetails = readContract(t, stub, contractId)
+	if contractDetails.DeliveryDetails.Incoterm != "CIF" || contractDetails.RiskTransfer.InsuranceBy != Party_Seller || contractDetails.RiskTransfer.CostTransferStatus != Shipment_Delivered {
+		t.Fatalf("incoterm %s with %+v", contractDetails.DeliveryDetails.Incoterm, contractDetails.RiskTransfer)
+	}
+	if _, err := invokeErr(stub, "submitTradeDocument", "buyer", contractId, Document_Insurance, "insurance"); err == nil {
+		t.Error("buyer supplied the insurance under CIF")
+	}
+
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId))
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+
+	//Under CIF the seller insures the main carriage, so shipment waits for the certificate
+	_, err := invokeErr(stub, "UpdateContractStatus", "transporter", contractId)
+	if err == nil || !strings.Contains(err.Error(), "insurance") {
+		t.Fatalf("shipped without an insurance certificate: %v", err)
+	}
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_Insurance, "insurance")
+	if _, err := invokeErr(stub, "submitTradeDocument", "seller", contractId, Document_Insurance, "insurance"); err == nil {
+		t.Error("insurance certificate submitted twice")
+	}
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	if readContract(t, stub, contractId).RiskTransfer.RiskTransferredDate == "" {
+		t.Error("risk did not pass to the buyer on shipment")
+	}
+
+	blNumber := "BL-" + contractId
+	invoke(t, stub, "issueBillOfLading", "transporter", contractId, `{"blNumber":"`+blNumber+`","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`)
+	invoke(t, stub, "endorseBillOfLading", "seller", blNumber, "sellerbank")
+	invoke(t, stub, "endorseBillOfLading", "sellerbank", blNumber, "buyerbank")
+	invoke(t, stub, "endorseBillOfLading", "buyerbank", blNumber, "buyer")
+	invoke(t, stub, "surrenderBillOfLading", "buyer", blNumber)
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId); err == nil {
+		t.Fatal("delivered without an import declaration")
+	}
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId)
+}
+
+func TestPenaltyAllocation(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.Local)
+	contractDetails := contract{ReadyForShipmentBySellerDate: "2026-05-04"}
+
+	//Under FOB the buyer's carriage starts at handover, so lateness is measured from the seller's ready date
+	contractDetails.RiskTransfer = &riskTransfer{incotermRule: Incoterms["FOB"]}
+	if carriageBy(contractDetails) != Party_Buyer || sellerHandoverDate(contractDetails, now).Day() != 4 {
+		t.Error("FOB handover is not the seller's ready date")
+	}
+	contractDetails.RiskTransfer = &riskTransfer{incotermRule: Incoterms["CIF"]}
+	if carriageBy(contractDetails) != Party_Seller || sellerHandoverDate(contractDetails, now).Day() != 10 {
+		t.Error("CIF handover is not the risk transfer date")
+	}
+
+	contractDetails.DeliveryDetails.DeliveryDate = "2026-05-20T00:00:00Z"
+	contractDetails.TotalTradeAmount = money{Minor: 10000, Currency: "USD"}
+	penalties, _ := evaluatePenaltyRules(contractDetails, []penaltyRule{{RuleId: "eb", RuleType: Rule_EarlyBonus, PercentagePerDay: 0.1}}, now)
+	if len(penalties) != 1 || penalties[0].CarriedBy != Party_Buyer {
+		t.Errorf("early delivery bonus %+v", penalties)
+	}
+}
+
+func TestLateCarriageCarriedBySeller(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractDetails := testContract()
+	contractDetails.DeliveryDetails.Incoterm = "CIF"
+	contractId := saveTestContract(t, stub, contractDetails)
+	moveDeliveryDate(t, stub, contractId, time.Now().AddDate(0, 0, -3))
+
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId))
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_Insurance, "insurance")
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+
+	var penalties []penaltyRecord
+	json.Unmarshal(query(t, stub, "getPenaltyRecords", contractId, "seller"), &penalties)
+	if len(penalties) == 0 || penalties[0].DaysLate != 3 || penalties[0].CarriedBy != Party_Seller {
+		t.Errorf("penalty records %+v", penalties)
+	}
+}
+
+// testSensorReading is a signed reading from the sensor a minute ago with 40% humidity
+func testSensorReading(contractId string, sequence int, temperature float64, sensor testKey) (string, string) {
+	reading := fmt.Sprintf(`{"contractId":"%s","sequence":%d,"timestamp":"%s","temperature":%g,"humidity":40}`, contractId, sequence, time.Now().Add(-time.Minute).Format(time.RFC3339), temperature)
+	hash := sha256.Sum256([]byte(reading))
+	return reading, sensor.sign(hex.EncodeToString(hash[:]))
+}
+
+func TestConditionMonitoring(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	contractDetails := testContract()
+	contractDetails.ConditionRules = []conditionRule{{Metric: "temperature", Min: 8, Max: 2}}
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	if _, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt); err == nil {
+		t.Fatal("condition rule with min above max saved")
+	}
+	contractDetails.ConditionRules = []conditionRule{{Metric: "temperature", Min: 2, Max: 8}, {Metric: "humidity", Min: 0, Max: 60}}
+	contractId := saveTestContract(t, stub, contractDetails)
+
+	sensor := newTestKey()
+	if _, err := invokeErr(stub, "registerSensor", "seller", contractId, "sensor1", sensor.publicKey); err == nil {
+		t.Fatal("sensor registered by someone other than the transporter")
+	}
+	invoke(t, stub, "registerSensor", "transporter", contractId, "sensor1", sensor.publicKey)
+
+	reading, signature := testSensorReading(contractId, 1, 5, sensor)
+	if _, err := invokeErr(stub, "ingestSensorReading", "sensor1", reading, signature); err == nil {
+		t.Fatal("reading accepted before the goods were handed over")
+	}
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "ingestSensorReading", "sensor1", reading, signature)
+	if _, err := invokeErr(stub, "ingestSensorReading", "sensor1", reading, signature); err == nil {
+		t.Error("replayed reading accepted")
+	}
+	if readContract(t, stub, contractId).ConditionBreached {
+		t.Fatal("reading inside the limits flagged as a breach")
+	}
+
+	breach, breachSignature := testSensorReading(contractId, 2, 11.5, sensor)
+	if _, err := invokeErr(stub, "ingestSensorReading", "sensor1", breach, signature); err == nil {
+		t.Error("reading accepted with another reading's signature")
+	}
+	invoke(t, stub, "ingestSensorReading", "sensor1", breach, breachSignature)
+	if contractDetails = readContract(t, stub, contractId); !contractDetails.ConditionBreached || contractDetails.FirstBreachAt == "" {
+		t.Fatal("breach not flagged on the contract")
+	}
+	var readings []conditionReading
+	json.Unmarshal(query(t, stub, "getConditionReadings", contractId, "buyer", "true"), &readings)
+	if len(readings) != 1 || readings[0].Breaches[0].Metric != "temperature" {
+		t.Fatalf("breaching readings %+v", readings)
+	}
+
+	//Breaching readings are attached to a dispute as evidence
+	if _, err := invokeErr(stub, "raiseDispute", "outsider", contractId, "cold chain broken"); err == nil {
+		t.Error("dispute raised by someone outside the contract")
+	}
+	invoke(t, stub, "raiseDispute", "buyer", contractId, "cold chain broken")
+	var disputes []dispute
+	json.Unmarshal(query(t, stub, "getContractDisputes", contractId, "seller"), &disputes)
+	if len(disputes) != 1 || len(disputes[0].Evidence) != 1 || disputes[0].Evidence[0].Sequence != 2 {
+		t.Errorf("disputes %+v", disputes)
+	}
+}
+
+// testLegsContract is testContract carried by road to the port, by the given mode from the port and by road to the buyer
+func testLegsContract(portOfLoading string, mainMode string) contract {
+	contractDetails := testContract()
+	contractDetails.DeliveryDetails.TransporterDetails = user{}
+	day := func(days int) string { return time.Now().AddDate(0, 0, days).Format(time.RFC3339) }
+	contractDetails.DeliveryDetails.TransportLegs = []transportLeg{
+		{Mode: "road", Transporter: user{UserId: "transporter", UserName: "Transporter"}, Origin: "Pune", Destination: "Nhava Sheva", DepartureDate: day(1), ArrivalDate: day(2)},
+		{Mode: mainMode, Transporter: user{UserId: "carrier", UserName: "Carrier"}, Origin: portOfLoading, Destination: "Hamburg Port", DepartureDate: day(3), ArrivalDate: day(15)},
+		{Mode: "road", Transporter: user{UserId: "haulier", UserName: "Haulier"}, Origin: "Hamburg Port", Destination: "Hamburg", DepartureDate: day(16), ArrivalDate: day(18)},
+	}
+	return contractDetails
+}
+
+func TestTransportLegs(t *testing.T) {
+	stub := newTestStub(t)
+	parties := newTestParties(t, stub)
+	invoke(t, stub, "initializeUser", "carrier")
+	invoke(t, stub, "initializeUser", "haulier")
+
+	tests := []struct {
+		name          string
+		portOfLoading string
+		mainMode      string
+	}{
+		{"legs that do not connect", "Mumbai", "sea"},
+		{"FOB without a sea leg", "Nhava Sheva", "air"},
+	}
+	for _, test := range tests {
+		contractAsBytes, _ := json.Marshal(testLegsContract(test.portOfLoading, test.mainMode))
+		if _, err := invokeErr(stub, "saveContract", string(contractAsBytes), testPricingSalt); err == nil {
+			t.Errorf("contract saved with %s", test.name)
+		}
+	}
+	contractId := saveTestContract(t, stub, testLegsContract("Nhava Sheva", "sea"))
+
+	//Every leg transporter sees the contract, the first one is the contract's transporter
+	for _, userId := range []string{"transporter", "carrier", "haulier"} {
+		var contracts []contract
+		json.Unmarshal(query(t, stub, "getContractDetailsByUserId", userId), &contracts)
+		if len(contracts) != 1 || contracts[0].ContractId != contractId {
+			t.Fatalf("%s sees %d contracts", userId, len(contracts))
+		}
+	}
+	contractDetails := readContract(t, stub, contractId)
+	if contractDetails.DeliveryDetails.TransporterDetails.UserId != "transporter" || contractDetails.DeliveryDetails.TransportLegs[2].LegNumber != 3 {
+		t.Fatalf("delivery details %+v", contractDetails.DeliveryDetails)
+	}
+	if roles := getContractRoles(contractDetails, "carrier"); len(roles) != 1 || roles[0] != Party_LegTransporter {
+		t.Errorf("carrier roles %v", roles)
+	}
+
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	if _, err := invokeErr(stub, "confirmHandover", "carrier", contractId, "1"); err == nil {
+		t.Fatal("handover before the shipment started")
+	}
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	if _, err := invokeErr(stub, "postTrackingEvent", "carrier", contractId, testTrackingEvent("departed", "Nhava Sheva", 1)); err == nil {
+		t.Error("tracking event from a transporter not yet carrying the goods")
+	}
+	if _, err := invokeErr(stub, "confirmHandover", "haulier", contractId, "1"); err == nil {
+		t.Error("handover confirmed by the transporter of a later leg")
+	}
+	invoke(t, stub, "confirmHandover", "carrier", contractId, "1")
+	invoke(t, stub, "postTrackingEvent", "carrier", contractId, testTrackingEvent("departed", "Nhava Sheva", 1))
+
+	//The bill of lading comes from the sea carrier
+	blNumber := "BL-" + contractId
+	if _, err := invokeErr(stub, "issueBillOfLading", "transporter", contractId, `{"blNumber":"`+blNumber+`","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`); err == nil {
+		t.Error("bill of lading issued by the road transporter")
+	}
+	invoke(t, stub, "issueBillOfLading", "carrier", contractId, `{"blNumber":"`+blNumber+`","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`)
+	invoke(t, stub, "endorseBillOfLading", "seller", blNumber, "sellerbank")
+	invoke(t, stub, "endorseBillOfLading", "sellerbank", blNumber, "buyerbank")
+	invoke(t, stub, "endorseBillOfLading", "buyerbank", blNumber, "buyer")
+	invoke(t, stub, "surrenderBillOfLading", "buyer", blNumber)
+
+	if _, err := invokeErr(stub, "UpdateContractStatus", "buyer", contractId); err == nil {
+		t.Fatal("delivered before the last leg started")
+	}
+	invoke(t, stub, "confirmHandover", "haulier", contractId, "2")
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId)
+	contractDetails = readContract(t, stub, contractId)
+	for _, leg := range contractDetails.DeliveryDetails.TransportLegs {
+		if leg.Status != Leg_Status_Completed {
+			t.Errorf("leg %d is %s", leg.LegNumber, leg.Status)
+		}
+	}
+	if handedOverTo := contractDetails.DeliveryDetails.TransportLegs[2].HandedOverTo; handedOverTo != "buyer" {
+		t.Errorf("last leg handed over to %s", handedOverTo)
+	}
+}
@@ -13,8 +13,8 @@ type DTC_Chaincode struct {
 
 func (t *DTC_Chaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
 	var err error
-	if len(args) != 0 {
-		return nil, errors.New("Incorrect number of arguments. Expecting 0")
+	if len(args) > 1 {
+		return nil, errors.New("Incorrect number of arguments. Expecting 0 or 1")
 	}
 
 	//Create database on blockchain
@@ -40,6 +40,120 @@ func (t *DTC_Chaincode) Invoke(stub shim.ChaincodeStubInterface, function string
 	} else if function == "UpdateContractStatus" {
 		// inserting attachment data in blockchain
 		return UpdateContractStatus(stub, args)
+	} else if function == "assignUserRole" {
+		// grant a role to a user
+		return assignUserRole(stub, args)
+	} else if function == "importWatchList" {
+		// import a new version of the sanctions watch list
+		return importWatchList(stub, args)
+	} else if function == "resolveScreeningReview" {
+		// clear or block a contract flagged for review
+		return resolveScreeningReview(stub, args)
+	} else if function == "setConfiguration" {
+		// store a new configuration version
+		return setConfiguration(stub, args)
+	} else if function == "registerPublicKey" {
+		// register a party's signing key
+		return registerPublicKey(stub, args)
+	} else if function == "publishFxRates" {
+		// publish dated FX rates
+		return publishFxRates(stub, args)
+	} else if function == "issueLetterOfCredit" {
+		// issue letter of credit for an accepted contract
+		return issueLetterOfCredit(stub, args)
+	} else if function == "amendLetterOfCredit" {
+		// propose an amendment to a letter of credit
+		return amendLetterOfCredit(stub, args)
+	} else if function == "respondLCAmendment" {
+		// accept or reject a letter of credit amendment
+		return respondLCAmendment(stub, args)
+	} else if function == "expireLetterOfCredit" {
+		// mark a letter of credit past its expiry date as expired
+		return expireLetterOfCredit(stub, args)
+	} else if function == "setOrganisationPenaltyRules" {
+		// store the default penalty rules of an organisation
+		return setOrganisationPenaltyRules(stub, args)
+	} else if function == "createInvoice" {
+		// seller issues an invoice or credit note
+		return createInvoice(stub, args)
+	} else if function == "recordMilestonePayment" {
+		// record a full or partial payment against a milestone
+		return recordMilestonePayment(stub, args)
+	} else if function == "offerReceivable" {
+		// offer an invoice to financiers
+		return offerReceivable(stub, args)
+	} else if function == "bidReceivable" {
+		// bid a discount rate on an offered invoice
+		return bidReceivable(stub, args)
+	} else if function == "acceptReceivableBid" {
+		// assign the receivable to the financier
+		return acceptReceivableBid(stub, args)
+	} else if function == "registerFinancing" {
+		// register a financed document by its hash
+		return registerFinancing(stub, args)
+	} else if function == "releaseFinancing" {
+		// release a financed document once repaid
+		return releaseFinancing(stub, args)
+	} else if function == "setCreditLimit" {
+		// set a bank's credit limit for a client and currency
+		return setCreditLimit(stub, args)
+	} else if function == "setTaxRates" {
+		// maintain a country's tax and duty rates
+		return setTaxRates(stub, args)
+	} else if function == "depositSettlementFunds" {
+		// credit a settlement balance
+		return depositSettlementFunds(stub, args)
+	} else if function == "withdrawSettlementFunds" {
+		// debit a settlement balance
+		return withdrawSettlementFunds(stub, args)
+	} else if function == "setFeeSchedule" {
+		// set a bank's fee schedule
+		return setFeeSchedule(stub, args)
+	} else if function == "issueGuarantee" {
+		// issue a guarantee or standby LC on a contract
+		return issueGuarantee(stub, args)
+	} else if function == "amendGuarantee" {
+		// propose a guarantee amendment
+		return amendGuarantee(stub, args)
+	} else if function == "respondGuaranteeAmendment" {
+		// accept or reject a guarantee amendment
+		return respondGuaranteeAmendment(stub, args)
+	} else if function == "claimGuarantee" {
+		// claim under a guarantee
+		return claimGuarantee(stub, args)
+	} else if function == "releaseGuarantee" {
+		// release a guarantee
+		return releaseGuarantee(stub, args)
+	} else if function == "postTrackingEvent" {
+		// record a shipment checkpoint
+		return postTrackingEvent(stub, args)
+	} else if function == "issueBillOfLading" {
+		// issue the electronic bill of lading
+		return issueBillOfLading(stub, args)
+	} else if function == "endorseBillOfLading" {
+		// endorse the bill of lading to the next holder
+		return endorseBillOfLading(stub, args)
+	} else if function == "surrenderBillOfLading" {
+		// surrender the bill of lading for delivery
+		return surrenderBillOfLading(stub, args)
+	} else if function == "submitTradeDocument" {
+		// supply an insurance or customs document the Incoterm calls for
+		return submitTradeDocument(stub, args)
+	} else if function == "registerSensor" {
+		// transporter registers a condition monitoring sensor for a contract
+		return registerSensor(stub, args)
+	} else if function == "ingestSensorReading" {
+		// signed temperature and humidity reading from a sensor
+		return ingestSensorReading(stub, args)
+	} else if function == "raiseDispute" {
+		// open a dispute with condition breaches as evidence
+		return raiseDispute(stub, args)
+	} else if function == "confirmHandover" {
+		// next leg's transporter confirms it has taken the goods over
+		return confirmHandover(stub, args)
+	} else if function == "recordDeliveredQuantities" {
+		// buyer records a short delivery against the contract lines
+		return recordDeliveredQuantities(stub, args)
 	}
 
 	return nil, nil
@@ -68,6 +182,114 @@ func (t *DTC_Chaincode) Query(stub shim.ChaincodeStubInterface, function string,
 	} else if function == "getNotificationCountStatus" {
 		// return notification status
 		return getNotificationCountStatus(stub, args)
+	} else if function == "getWatchList" {
+		// return sanctions watch list
+		return getWatchList(stub, args)
+	} else if function == "getScreeningResults" {
+		// return screening results of contract
+		return getScreeningResults(stub, args)
+	} else if function == "getConfiguration" {
+		// return configuration in effect on a date
+		return getConfiguration(stub, args)
+	} else if function == "getConfigurationChanges" {
+		// return configuration change history
+		return getConfigurationChanges(stub, args)
+	} else if function == "getPricingAccessHash" {
+		// return hash a trading party signs to read pricing
+		return getPricingAccessHash(stub, args)
+	} else if function == "getContractPricing" {
+		// return contract pricing to a trading party
+		return getContractPricing(stub, args)
+	} else if function == "verifyContractPricing" {
+		// check pricing data against the hash on the contract
+		return verifyContractPricing(stub, args)
+	} else if function == "getPublicKeys" {
+		// return registered signing keys of user
+		return getPublicKeys(stub, args)
+	} else if function == "getContractSigningHash" {
+		// return hash a party must sign for the current contract version
+		return getContractSigningHash(stub, args)
+	} else if function == "getContractSignatures" {
+		// return signatures recorded on contract
+		return getContractSignatures(stub, args)
+	} else if function == "getSignedContractVersion" {
+		// return contract version covered by a signature
+		return getSignedContractVersion(stub, args)
+	} else if function == "getFxRate" {
+		// return FX rate for a currency pair on a date
+		return getFxRate(stub, args)
+	} else if function == "getTradeTotals" {
+		// return trade totals in a reporting currency
+		return getTradeTotals(stub, args)
+	} else if function == "getLetterOfCredit" {
+		// return letter of credit to its parties
+		return getLetterOfCredit(stub, args)
+	} else if function == "getOrganisationPenaltyRules" {
+		// return penalty rule versions of an organisation
+		return getOrganisationPenaltyRules(stub, args)
+	} else if function == "getPenaltyRecords" {
+		// return penalties applied to a contract
+		return getPenaltyRecords(stub, args)
+	} else if function == "getContractInvoices" {
+		// return invoices and credit notes of contract
+		return getContractInvoices(stub, args)
+	} else if function == "getPaymentSchedule" {
+		// return milestone amounts due and paid
+		return getPaymentSchedule(stub, args)
+	} else if function == "getContractPayments" {
+		// return payments recorded on the contract
+		return getContractPayments(stub, args)
+	} else if function == "getPaymentReconciliation" {
+		// compare payments against invoiced amounts
+		return getPaymentReconciliation(stub, args)
+	} else if function == "getReceivableOffer" {
+		// return the receivable offer and visible bids
+		return getReceivableOfferForUser(stub, args)
+	} else if function == "getOpenReceivables" {
+		// return receivables open for bidding
+		return getOpenReceivables(stub, args)
+	} else if function == "getDocumentHash" {
+		// return the registry hash of a trade document
+		return getDocumentHash(stub, args)
+	} else if function == "getFinancingStatus" {
+		// return whether a document is already financed
+		return getFinancingStatus(stub, args)
+	} else if function == "getCreditUtilisation" {
+		// return a bank's limits and open exposure
+		return getCreditUtilisation(stub, args)
+	} else if function == "getTaxRates" {
+		// return a country's tax and duty rates
+		return getTaxRates(stub, args)
+	} else if function == "getSettlementBalances" {
+		// return settlement balances per currency
+		return getSettlementBalances(stub, args)
+	} else if function == "getAccruedInterest" {
+		// return late payment interest accrued as of a date
+		return getAccruedInterest(stub, args)
+	} else if function == "getFeeSchedule" {
+		// return a bank's fee schedule
+		return getBankFeeSchedule(stub, args)
+	} else if function == "getFeeStatement" {
+		// return a party's fees on a contract
+		return getFeeStatement(stub, args)
+	} else if function == "getGuarantee" {
+		// return a guarantee
+		return getGuarantee(stub, args)
+	} else if function == "getContractGuarantees" {
+		// return the guarantees linked to a contract
+		return getContractGuarantees(stub, args)
+	} else if function == "getShipmentTimeline" {
+		// return shipment checkpoints in time order
+		return getShipmentTimeline(stub, args)
+	} else if function == "getBillOfLading" {
+		// return the bill of lading and its endorsements
+		return getBillOfLading(stub, args)
+	} else if function == "getConditionReadings" {
+		// return sensor readings for a contract, optionally only breaches
+		return getConditionReadings(stub, args)
+	} else if function == "getContractDisputes" {
+		// return disputes raised on a contract with their evidence
+		return getContractDisputes(stub, args)
 	}
 
 	return nil, nil
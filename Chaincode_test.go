@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var testTransactions int
+
+const testPricingSalt = "6a0f3c9e51b27d84e3f05a1c9b6d2e70"
+
+// newTestStub initialises the chaincode with an admin and the parties of testContract
+func newTestStub(t *testing.T) *shim.MockStub {
+	stub := shim.NewMockStub("trade", new(DTC_Chaincode))
+	_, err := stub.MockInit("init", "init", []string{"admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userId := range []string{"seller", "sellerbank", "buyer", "buyerbank", "transporter"} {
+		invoke(t, stub, "initializeUser", userId)
+	}
+	return stub
+}
+
+func invokeErr(stub *shim.MockStub, function string, args ...string) ([]byte, error) {
+	testTransactions++
+	return stub.MockInvoke("tx"+strconv.Itoa(testTransactions), function, args)
+}
+
+func invoke(t *testing.T, stub *shim.MockStub, function string, args ...string) []byte {
+	t.Helper()
+	result, err := invokeErr(stub, function, args...)
+	if err != nil {
+		t.Fatalf("%s %v: %v", function, args, err)
+	}
+	return result
+}
+
+func query(t *testing.T, stub *shim.MockStub, function string, args ...string) []byte {
+	t.Helper()
+	result, err := stub.MockQuery(function, args)
+	if err != nil {
+		t.Fatalf("%s %v: %v", function, args, err)
+	}
+	return result
+}
+
+// testContract is a one line FOB contract delivered within the default configuration limits
+func testContract() contract {
+	var contractDetails contract
+	contractDetails.SellerDetails.Seller = user{UserId: "seller", UserName: "Seller Co", Address: "Pune, India"}
+	contractDetails.SellerDetails.SellerBank = user{UserId: "sellerbank", UserName: "Seller Bank"}
+	contractDetails.BuyerDetails.Buyer = user{UserId: "buyer", UserName: "Buyer Ltd", Address: "Hamburg, Germany"}
+	contractDetails.BuyerDetails.BuyerBank = user{UserId: "buyerbank", UserName: "Buyer Bank"}
+	contractDetails.TradeDetails = []product{{ProductName: "Widget", ProductPrice: "10.50", ProductQuantity: "4", TotalAmount: "42.00"}}
+	contractDetails.TradeConditions.PaymentDuration = "30"
+	contractDetails.TradeConditions.TransportDuration = "15"
+	contractDetails.TradeConditions.Currency = "USD"
+	contractDetails.DeliveryDetails.PickupAddress = "Pune"
+	contractDetails.DeliveryDetails.DeliveryAddress = "Hamburg"
+	contractDetails.DeliveryDetails.DeliveryDate = time.Now().AddDate(0, 0, 20).Format(time.RFC3339)
+	contractDetails.DeliveryDetails.Incoterm = "FOB"
+	contractDetails.DeliveryDetails.TransporterDetails = user{UserId: "transporter", UserName: "Transporter"}
+	return contractDetails
+}
+
+// saveTestContract saves the contract and returns its id
+func saveTestContract(t *testing.T, stub *shim.MockStub, contractDetails contract) string {
+	t.Helper()
+	contractAsBytes, _ := json.Marshal(contractDetails)
+	invoke(t, stub, "saveContract", string(contractAsBytes), testPricingSalt)
+
+	contractIdList, _ := getUserContractList(stub, contractDetails.BuyerDetails.Buyer.UserId)
+	if len(contractIdList) == 0 {
+		t.Fatal("contract not saved")
+	}
+	return contractIdList[len(contractIdList)-1]
+}
+
+func readContract(t *testing.T, stub *shim.MockStub, contractId string) contract {
+	t.Helper()
+	contractDetails, err := getContractDetails(stub, contractId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contractDetails
+}
+
+type testKey struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string
+}
+
+func newTestKey() testKey {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	return testKey{privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+}
+
+// sign signs a hex encoded hash and returns the base64 signature the chaincode expects
+func (key testKey) sign(hashAsHex string) string {
+	hash, _ := hex.DecodeString(hashAsHex)
+	r, s, _ := ecdsa.Sign(rand.Reader, key.privateKey, hash)
+	signature, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+// testParties holds the signing keys registered for the buyer and the seller bank
+type testParties struct {
+	buyer      testKey
+	sellerBank testKey
+}
+
+// newTestParties registers signing keys and funds the banks' settlement accounts
+func newTestParties(t *testing.T, stub *shim.MockStub) testParties {
+	parties := testParties{newTestKey(), newTestKey()}
+	invoke(t, stub, "registerPublicKey", "admin", "buyer", parties.buyer.publicKey)
+	invoke(t, stub, "registerPublicKey", "admin", "sellerbank", parties.sellerBank.publicKey)
+	invoke(t, stub, "assignUserRole", "admin", "admin", Role_SettlementAgent)
+	invoke(t, stub, "depositSettlementFunds", "admin", "sellerbank", "1000", "USD")
+	invoke(t, stub, "depositSettlementFunds", "admin", "buyerbank", "1000", "USD")
+	return parties
+}
+
+// signedTransition moves the contract on with the user's signature over the current contract version
+func signedTransition(t *testing.T, stub *shim.MockStub, userId string, contractId string, key testKey) {
+	t.Helper()
+	var signingHash contractSignature
+	json.Unmarshal(query(t, stub, "getContractSigningHash", contractId), &signingHash)
+	invoke(t, stub, "UpdateContractStatus", userId, contractId, key.sign(signingHash.ContractHash))
+}
+
+// advanceToLCApproved takes a new contract through acceptance and a letter of credit advised to the seller bank
+func advanceToLCApproved(t *testing.T, stub *shim.MockStub, contractId string, parties testParties) {
+	t.Helper()
+	signedTransition(t, stub, "buyer", contractId, parties.buyer)
+	invoke(t, stub, "submitTradeDocument", "seller", contractId, Document_ExportDeclaration, "export")
+	invoke(t, stub, "submitTradeDocument", "buyer", contractId, Document_ImportDeclaration, "import")
+	invoke(t, stub, "issueLetterOfCredit", "buyerbank", contractId, testLetterOfCredit("LC-"+contractId))
+	invoke(t, stub, "UpdateContractStatus", "buyerbank", contractId)
+	signedTransition(t, stub, "sellerbank", contractId, parties.sellerBank)
+}
+
+// advanceToDelivered ships the contract and the buyer takes delivery of the full quantity
+func advanceToDelivered(t *testing.T, stub *shim.MockStub, contractId string, parties testParties) {
+	t.Helper()
+	advanceToShipped(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "buyer", contractId)
+}
+
+// advanceToShipped ships the contract and surrenders its bill of lading to the buyer
+func advanceToShipped(t *testing.T, stub *shim.MockStub, contractId string, parties testParties) {
+	t.Helper()
+	advanceToLCApproved(t, stub, contractId, parties)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+	invoke(t, stub, "UpdateContractStatus", "transporter", contractId)
+	blNumber := "BL-" + contractId
+	invoke(t, stub, "issueBillOfLading", "transporter", contractId, `{"blNumber":"`+blNumber+`","portOfLoading":"Nhava Sheva","portOfDischarge":"Hamburg"}`)
+	invoke(t, stub, "endorseBillOfLading", "seller", blNumber, "sellerbank")
+	invoke(t, stub, "endorseBillOfLading", "sellerbank", blNumber, "buyerbank")
+	invoke(t, stub, "endorseBillOfLading", "buyerbank", blNumber, "buyer")
+	invoke(t, stub, "surrenderBillOfLading", "buyer", blNumber)
+}
+
+// advanceToInvoiced delivers the contract and sends the seller's invoice for payment
+func advanceToInvoiced(t *testing.T, stub *shim.MockStub, contractId string, parties testParties) {
+	t.Helper()
+	advanceToDelivered(t, stub, contractId, parties)
+	invoke(t, stub, "createInvoice", "seller", contractId, `{}`)
+	invoke(t, stub, "UpdateContractStatus", "seller", contractId)
+}
+
+func settlementBalanceOf(t *testing.T, stub *shim.MockStub, userId string) string {
+	t.Helper()
+	var balances []money
+	json.Unmarshal(query(t, stub, "getSettlementBalances", userId), &balances)
+	if len(balances) == 0 {
+		return "0"
+	}
+	return balances[0].String()
+}
+
+// moveDeliveryDate rewrites the agreed delivery date of a saved contract, which saveContract would not accept in the past
+func moveDeliveryDate(t *testing.T, stub *shim.MockStub, contractId string, deliveryDate time.Time) {
+	t.Helper()
+	contractDetails := readContract(t, stub, contractId)
+	contractDetails.DeliveryDetails.DeliveryDate = deliveryDate.Format(time.RFC3339)
+	stub.MockTransactionStart("moveDeliveryDate")
+	ok := updateContractListByContractID(stub, contractId, contractDetails)
+	stub.MockTransactionEnd("moveDeliveryDate")
+	if !ok {
+		t.Fatal("delivery date not updated")
+	}
+}
+
+// pricingSignature signs the day's pricing access hash for the user
+func pricingSignature(t *testing.T, stub *shim.MockStub, userId string, key testKey) string {
+	t.Helper()
+	var access pricingAccess
+	json.Unmarshal(query(t, stub, "getPricingAccessHash", userId), &access)
+	return key.sign(access.AccessHash)
+}
+
+// testLetterOfCredit is a letter of credit for the whole of testContract with 5% tolerance
+func testLetterOfCredit(lcNumber string) string {
+	return `{"lcNumber":"` + lcNumber + `","amount":{"amount":"42.00","currency":"USD"},"tolerancePercentage":5,"expiryDate":"2099-01-01","expiryPlace":"Hamburg","latestShipmentDate":"2098-12-01","requiredDocuments":["Invoice","Bill of Lading"]}`
+}
@@ -3,33 +3,58 @@ package main
 import "time"
 
 type contract struct {
-	ContractId                                  string          `json:"contractId"`
-	SellerDetails                               sellerDetails   `json:"sellerDetails"`
-	BuyerDetails                                buyerDetails    `json:"buyerDetails"`
-	TradeDetails                                []product       `json:"tradeDetails"`
-	TradeConditions                             tradeConditions `json:"tradeConditions"`
-	TotalTradeAmount                            float64         `json:"totalTradeAmount"`
-	DiscountedAmount                            float64         `json:"discountedAmount"`
-	DiscountPercentage                          float64         `json:"discountPercentage"`
-	DeliveryDetails                             deliveryDetails `json:"deliveryDetails"`
-	ContractCreateDate                          time.Time       `json:"createDate"`
-	IsLCAttached                                bool            `json:"isLCAttached"`
-	IsPOAttached                                bool            `json:"isPOAttached"`
-	IsInvoiceListAttached                       bool            `json:"isInvoiceListAttached"`
-	IsBillOfLedingAttached                      bool            `json:"isBillOfLedingAttached"`
-	ActionPendingOn                             string          `json:"actionPendingOn"`
-	ContractStatus                              string          `json:"contractStatus"`
-	LastUpdatedDate                             string          `json:"LastUpdatedDate"`
-	ApprovedContractByBuyerDate                 string          `json:"ApprovedContractByBuyerDate"`
-	LCCreatedByBuyerBankDate                    string          `json:"LCCreatedByBuyerBankDate"`
-	LCApprovedBySellerBankDate                  string          `json:"LCApprovedBySellerBankDate"`
-	ReadyForShipmentBySellerDate                string          `json:"ReadyForShipmentBySellerDate"`
-	ShipmentInProgressByTransDate               string          `json:"ShipmentInProgressByTransDate"`
-	ShipmentDeliveredByBuyerDate                string          `json:"ShipmentDeliveredByBuyerDate"`
-	InvoiceCreatedBySellerDate                  string          `json:"InvoiceCreatedBySellerDate"`
-	PaymentCompletedToSellerBySellerBankDate    string          `json:"PaymentCompletedToSellerBySellerBankDate"`
-	PaymentCompletedToSellerBankByBuyerBankDate string          `json:"PaymentCompletedToSellerBankByBuyerBankDate"`
-	ContractCompletedByBuyerDate                string          `json:"ContractCompletedByBuyerDate"`
+	ContractId                                  string             `json:"contractId"`
+	SellerDetails                               sellerDetails      `json:"sellerDetails"`
+	BuyerDetails                                buyerDetails       `json:"buyerDetails"`
+	TradeDetails                                []product          `json:"tradeDetails"`
+	TradeConditions                             tradeConditions    `json:"tradeConditions"`
+	TotalTradeAmount                            money              `json:"totalTradeAmount"`
+	DiscountedAmount                            money              `json:"discountedAmount"`
+	DiscountPercentage                          float64            `json:"discountPercentage"`
+	DeliveryDetails                             deliveryDetails    `json:"deliveryDetails"`
+	ContractCreateDate                          time.Time          `json:"createDate"`
+	IsLCAttached                                bool               `json:"isLCAttached"`
+	IsPOAttached                                bool               `json:"isPOAttached"`
+	IsInvoiceListAttached                       bool               `json:"isInvoiceListAttached"`
+	IsBillOfLedingAttached                      bool               `json:"isBillOfLedingAttached"`
+	ActionPendingOn                             string             `json:"actionPendingOn"`
+	ContractStatus                              string             `json:"contractStatus"`
+	LastUpdatedDate                             string             `json:"LastUpdatedDate"`
+	ApprovedContractByBuyerDate                 string             `json:"ApprovedContractByBuyerDate"`
+	LCCreatedByBuyerBankDate                    string             `json:"LCCreatedByBuyerBankDate"`
+	LCApprovedBySellerBankDate                  string             `json:"LCApprovedBySellerBankDate"`
+	ReadyForShipmentBySellerDate                string             `json:"ReadyForShipmentBySellerDate"`
+	ShipmentInProgressByTransDate               string             `json:"ShipmentInProgressByTransDate"`
+	ShipmentDeliveredByBuyerDate                string             `json:"ShipmentDeliveredByBuyerDate"`
+	InvoiceCreatedBySellerDate                  string             `json:"InvoiceCreatedBySellerDate"`
+	PaymentCompletedToSellerBySellerBankDate    string             `json:"PaymentCompletedToSellerBySellerBankDate"`
+	PaymentCompletedToSellerBankByBuyerBankDate string             `json:"PaymentCompletedToSellerBankByBuyerBankDate"`
+	ContractCompletedByBuyerDate                string             `json:"ContractCompletedByBuyerDate"`
+	ScreeningStatus                             string             `json:"screeningStatus"`
+	ConfigVersion                               int                `json:"configVersion"`
+	SellerBankFeeVersion                        int                `json:"sellerBankFeeVersion"`
+	BuyerBankFeeVersion                         int                `json:"buyerBankFeeVersion"`
+	PricingHash                                 string             `json:"pricingHash"`
+	ContractVersion                             int                `json:"contractVersion"`
+	LCNumber                                    string             `json:"lcNumber"`
+	PenaltyRules                                []penaltyRule      `json:"penaltyRules"`
+	PenaltyRuleSource                           string             `json:"penaltyRuleSource"`
+	InvoiceNumbers                              []string           `json:"invoiceNumbers"`
+	PaymentSchedule                             []milestone        `json:"paymentSchedule"`
+	Taxes                                       *contractTaxes     `json:"taxes,omitempty"`
+	PaymentDueDate                              string             `json:"paymentDueDate"`
+	LateInterest                                *lateInterestTerms `json:"lateInterest,omitempty"`
+	ChargesClause                               string             `json:"chargesClause"`
+	GuaranteeNumbers                            []string           `json:"guaranteeNumbers"`
+	SecuredByStandby                            bool               `json:"securedByStandby"`
+	CurrentLocation                             string             `json:"currentLocation"`
+	LocationUpdatedAt                           string             `json:"locationUpdatedAt"`
+	BLNumber                                    string             `json:"blNumber"`
+	RiskTransfer                                *riskTransfer      `json:"riskTransfer,omitempty"`
+	TradeDocuments                              []tradeDocument    `json:"tradeDocuments"`
+	ConditionRules                              []conditionRule    `json:"conditionRules"`
+	ConditionBreached                           bool               `json:"conditionBreached"`
+	FirstBreachAt                               string             `json:"firstBreachAt"`
 }
 
 type tradeConditions struct {
@@ -40,11 +65,14 @@ type tradeConditions struct {
 }
 
 type product struct {
-	ProductName     string `json:"productName"`
-	ProductDesc     string `json:"productDesc"`
-	ProductPrice    string `json:"productPrice"`
-	ProductQuantity string `json:"productQuantity"`
-	TotalAmount     string `json:"totalAmount"`
+	ProductName       string `json:"productName"`
+	ProductDesc       string `json:"productDesc"`
+	ProductPrice      string `json:"productPrice"`
+	ProductQuantity   string `json:"productQuantity"`
+	TotalAmount       string `json:"totalAmount"`
+	HSCode            string `json:"hsCode"`
+	TaxCategory       string `json:"taxCategory"`
+	DeliveredQuantity string `json:"deliveredQuantity"`
 }
 type sellerDetails struct {
 	Seller     user `json:"seller"`
@@ -56,11 +84,13 @@ type buyerDetails struct {
 }
 
 type deliveryDetails struct {
-	PickupAddress      string `json:"pickupAddress"`
-	DeliveryAddress    string `json:"deliveryAddress"`
-	DeliveryDate       string `json:"deliveryDate"`
-	Incoterm           string `json:"incoterm"`
-	TransporterDetails user   `json:"transporterDetails"`
+	PickupAddress      string         `json:"pickupAddress"`
+	DeliveryAddress    string         `json:"deliveryAddress"`
+	DeliveryDate       string         `json:"deliveryDate"`
+	Incoterm           string         `json:"incoterm"`
+	DestinationCountry string         `json:"destinationCountry"`
+	TransporterDetails user           `json:"transporterDetails"`
+	TransportLegs      []transportLeg `json:"transportLegs"`
 }
 
 type user struct {
@@ -75,12 +105,14 @@ type staticData struct {
 	CurrentMonthContracts int            `josn:"currentMonthContracts"`
 	LastMonthContracts    int            `json:"lastMonthContracts"`
 	NotificationCount     int            `json:"notificationCount"`
+	NotificationByRole    map[string]int `json:"notificationByRole"`
 	CountStatus           countStatus    `json:"countStatus"`
 	ProgressStatus        progressStatus `json:"progressStatus"`
 	PaymentStatus         paymentStatus  `json:"paymentStatus"`
 	ShipmentStatus        shipmentStatus `json:"shipmentStatus"`
 	DeliveryStatus        deliveryStatus `json:"deliveryStatus"`
 	ContractList          []contract     `json:"contractList"`
+	TradeTotals           *tradeTotals   `json:"tradeTotals,omitempty"`
 }
 
 type countStatus struct {
@@ -91,6 +123,16 @@ type countStatus struct {
 	CompletedCount int `json:"completedCount"`
 }
 
+type notificationCountStatus struct {
+	countStatus
+	ByRole map[string]countStatus `json:"byRole"`
+}
+
+type pendingAction struct {
+	Role     string   `json:"role"`
+	Contract contract `json:"contract"`
+}
+
 type progressStatus struct {
 	Ontime    int `json:"ontime"`
 	Delayed   int `json:"delayed"`
@@ -98,10 +140,12 @@ type progressStatus struct {
 }
 
 type paymentStatus struct {
-	PendingBuyerBank  int `json:"pendingBuyerBank"`
-	PendingSellerBank int `json:"pendingSellerBank"`
-	CompletedBuyer    int `json:"completedBuyer"`
-	PendingBuyer      int `json:"pendingBuyer"`
+	PendingBuyerBank  int     `json:"pendingBuyerBank"`
+	PendingSellerBank int     `json:"pendingSellerBank"`
+	CompletedBuyer    int     `json:"completedBuyer"`
+	PendingBuyer      int     `json:"pendingBuyer"`
+	DueMilestones     int     `json:"dueMilestones"`
+	Outstanding       []money `json:"outstanding"`
 }
 
 type shipmentStatus struct {
@@ -115,3 +159,586 @@ type deliveryStatus struct {
 	OnTimeDelivery int `json:"onTimeDelivery"`
 	Delayed        int `json:"delayed"`
 }
+
+type watchList struct {
+	Version    int              `json:"version"`
+	ImportedBy string           `json:"importedBy"`
+	ImportDate string           `json:"importDate"`
+	Entries    []watchListEntry `json:"entries"`
+}
+
+type watchListEntry struct {
+	EntryType string `json:"entryType"`
+	Value     string `json:"value"`
+	ListName  string `json:"listName"`
+	Action    string `json:"action"`
+}
+
+type screeningResult struct {
+	ContractId       string           `json:"contractId"`
+	Stage            string           `json:"stage"`
+	WatchListVersion int              `json:"watchListVersion"`
+	ScreenDate       string           `json:"screenDate"`
+	Result           string           `json:"result"`
+	Matches          []screeningMatch `json:"matches"`
+	ReviewedBy       string           `json:"reviewedBy"`
+}
+
+type screeningMatch struct {
+	Party     string `json:"party"`
+	Field     string `json:"field"`
+	Value     string `json:"value"`
+	EntryType string `json:"entryType"`
+	ListName  string `json:"listName"`
+	Action    string `json:"action"`
+}
+
+type configuration struct {
+	Version                   int            `json:"version"`
+	EffectiveFrom             string         `json:"effectiveFrom"`
+	ChangedBy                 string         `json:"changedBy"`
+	ChangeDate                string         `json:"changeDate"`
+	MaxDaysPaymentDuration    int            `json:"maxDaysPaymentDuration"`
+	MinDaysPaymentDuration    int            `json:"minDaysPaymentDuration"`
+	MinDaysTransportDuration  int            `json:"minDaysTransportDuration"`
+	MaxDaysTransportDuration  int            `json:"maxDaysTransportDuration"`
+	MinDaysDeliveryDuration   int            `json:"minDaysDeliveryDuration"`
+	MaxDaysDeliveryDuration   int            `json:"maxDaysDeliveryDuration"`
+	LatestContractsCount      int            `json:"latestContractsCount"`
+	LateDeliveryDiscountTiers []discountTier `json:"lateDeliveryDiscountTiers"`
+}
+
+type discountTier struct {
+	MinDays    int     `json:"minDays"`
+	MaxDays    int     `json:"maxDays"`
+	Percentage float64 `json:"percentage"`
+}
+
+type contractPricing struct {
+	ContractId         string           `json:"contractId"`
+	Salt               string           `json:"salt"`
+	TradeDetails       []productPricing `json:"tradeDetails"`
+	TotalTradeAmount   money            `json:"totalTradeAmount"`
+	DiscountedAmount   money            `json:"discountedAmount"`
+	DiscountPercentage float64          `json:"discountPercentage"`
+	Taxes              *contractTaxes   `json:"taxes,omitempty"`
+}
+
+type pricingAccess struct {
+	UserId     string `json:"userId"`
+	AccessDate string `json:"accessDate"`
+	AccessHash string `json:"accessHash"`
+}
+
+type productPricing struct {
+	ProductName  string `json:"productName"`
+	ProductPrice string `json:"productPrice"`
+	TotalAmount  string `json:"totalAmount"`
+}
+
+type publicKey struct {
+	UserId         string `json:"userId"`
+	PublicKey      string `json:"publicKey"`
+	RegisteredDate string `json:"registeredDate"`
+	Active         bool   `json:"active"`
+}
+
+type contractSignature struct {
+	ContractId      string `json:"contractId"`
+	ContractVersion int    `json:"contractVersion"`
+	ContractHash    string `json:"contractHash"`
+	UserId          string `json:"userId"`
+	Role            string `json:"role"`
+	Status          string `json:"status"`
+	Signature       string `json:"signature"`
+	SignedDate      string `json:"signedDate"`
+}
+
+type fxRate struct {
+	FromCurrency  string `json:"fromCurrency"`
+	ToCurrency    string `json:"toCurrency"`
+	RateDate      string `json:"rateDate"`
+	Rate          string `json:"rate"`
+	PublishedBy   string `json:"publishedBy"`
+	PublishedDate string `json:"publishedDate"`
+}
+
+type tradeTotals struct {
+	ReportingCurrency string   `json:"reportingCurrency"`
+	RateDate          string   `json:"rateDate"`
+	Total             money    `json:"total"`
+	ByCurrency        []money  `json:"byCurrency"`
+	RatesUsed         []fxRate `json:"ratesUsed"`
+}
+
+type letterOfCredit struct {
+	LCNumber            string        `json:"lcNumber"`
+	ContractId          string        `json:"contractId"`
+	IssuingBank         string        `json:"issuingBank"`
+	AdvisingBank        string        `json:"advisingBank"`
+	Applicant           string        `json:"applicant"`
+	Beneficiary         string        `json:"beneficiary"`
+	Amount              money         `json:"amount"`
+	TolerancePercentage float64       `json:"tolerancePercentage"`
+	ExpiryDate          string        `json:"expiryDate"`
+	ExpiryPlace         string        `json:"expiryPlace"`
+	LatestShipmentDate  string        `json:"latestShipmentDate"`
+	RequiredDocuments   []string      `json:"requiredDocuments"`
+	Status              string        `json:"status"`
+	IssueDate           string        `json:"issueDate"`
+	Amendments          []lcAmendment `json:"amendments"`
+}
+
+type lcAmendment struct {
+	AmendmentNumber     int      `json:"amendmentNumber"`
+	Amount              money    `json:"amount"`
+	TolerancePercentage *float64 `json:"tolerancePercentage,omitempty"`
+	ExpiryDate          string   `json:"expiryDate"`
+	ExpiryPlace         string   `json:"expiryPlace"`
+	LatestShipmentDate  string   `json:"latestShipmentDate"`
+	RequiredDocuments   []string `json:"requiredDocuments"`
+	Status              string   `json:"status"`
+	RequestDate         string   `json:"requestDate"`
+	ResponseDate        string   `json:"responseDate"`
+}
+
+type penaltyRuleSet struct {
+	OrganisationId string        `json:"organisationId"`
+	Version        int           `json:"version"`
+	Rules          []penaltyRule `json:"rules"`
+	UpdatedDate    string        `json:"updatedDate"`
+}
+
+type penaltyRule struct {
+	RuleId           string         `json:"ruleId"`
+	RuleType         string         `json:"ruleType"`
+	GraceDays        int            `json:"graceDays"`
+	Tiers            []discountTier `json:"tiers"`
+	PercentagePerDay float64        `json:"percentagePerDay"`
+	CapPercentage    float64        `json:"capPercentage"`
+}
+
+type penaltyRecord struct {
+	ContractId   string  `json:"contractId"`
+	RuleId       string  `json:"ruleId"`
+	RuleType     string  `json:"ruleType"`
+	RuleSource   string  `json:"ruleSource"`
+	DeliveryDate string  `json:"deliveryDate"`
+	ShipmentDate string  `json:"shipmentDate"`
+	DaysLate     int     `json:"daysLate"`
+	GraceDays    int     `json:"graceDays"`
+	Percentage   float64 `json:"percentage"`
+	CapApplied   bool    `json:"capApplied"`
+	BaseAmount   money   `json:"baseAmount"`
+	Amount       money   `json:"amount"`
+	AppliedDate  string  `json:"appliedDate"`
+	RiskStage    string  `json:"riskStage"`
+	CarriedBy    string  `json:"carriedBy"`
+}
+
+type invoice struct {
+	InvoiceNumber         string        `json:"invoiceNumber"`
+	InvoiceType           string        `json:"invoiceType"`
+	OriginalInvoiceNumber string        `json:"originalInvoiceNumber"`
+	ContractId            string        `json:"contractId"`
+	SellerId              string        `json:"sellerId"`
+	BuyerId               string        `json:"buyerId"`
+	Currency              string        `json:"currency"`
+	InvoiceDate           string        `json:"invoiceDate"`
+	DueDate               string        `json:"dueDate"`
+	Lines                 []invoiceLine `json:"lines"`
+	SubTotal              money         `json:"subTotal"`
+	DiscountAmount        money         `json:"discountAmount"`
+	TaxAmount             money         `json:"taxAmount"`
+	DutyAmount            money         `json:"dutyAmount"`
+	TotalAmount           money         `json:"totalAmount"`
+}
+
+type invoiceLine struct {
+	LineNumber         int     `json:"lineNumber"`
+	ProductName        string  `json:"productName"`
+	UnitPrice          string  `json:"unitPrice"`
+	Quantity           string  `json:"quantity"`
+	GrossAmount        money   `json:"grossAmount"`
+	DiscountPercentage float64 `json:"discountPercentage"`
+	DiscountAmount     money   `json:"discountAmount"`
+	NetAmount          money   `json:"netAmount"`
+	TaxRate            float64 `json:"taxRate"`
+	TaxAmount          money   `json:"taxAmount"`
+	DutyRate           float64 `json:"dutyRate"`
+	DutyAmount         money   `json:"dutyAmount"`
+	TotalAmount        money   `json:"totalAmount"`
+}
+
+type invoiceRequest struct {
+	InvoiceNumber         string               `json:"invoiceNumber"`
+	InvoiceType           string               `json:"invoiceType"`
+	OriginalInvoiceNumber string               `json:"originalInvoiceNumber"`
+	Lines                 []invoiceLineRequest `json:"lines"`
+}
+
+type invoiceLineRequest struct {
+	LineNumber int     `json:"lineNumber"`
+	Quantity   string  `json:"quantity"`
+	TaxRate    float64 `json:"taxRate"`
+}
+
+type milestone struct {
+	MilestoneId   string  `json:"milestoneId"`
+	Description   string  `json:"description"`
+	TriggerStatus string  `json:"triggerStatus"`
+	Percentage    float64 `json:"percentage"`
+	DueDays       int     `json:"dueDays"`
+}
+
+type milestoneStatus struct {
+	MilestoneId   string `json:"milestoneId"`
+	Status        string `json:"status"`
+	TriggeredDate string `json:"triggeredDate"`
+	DueDate       string `json:"dueDate"`
+	AmountDue     money  `json:"amountDue"`
+	AmountPaid    money  `json:"amountPaid"`
+}
+
+type paymentRecord struct {
+	ContractId    string `json:"contractId"`
+	InvoiceNumber string `json:"invoiceNumber,omitempty"`
+	PaymentType   string `json:"paymentType"`
+	PayerId       string `json:"payerId"`
+	PayeeId       string `json:"payeeId"`
+	Amount        money  `json:"amount"`
+	BankReference string `json:"bankReference"`
+	ValueDate     string `json:"valueDate"`
+	RecordedDate  string `json:"recordedDate"`
+	Settled       bool   `json:"settled"`
+}
+
+type paymentRequest struct {
+	InvoiceNumber string `json:"invoiceNumber"`
+	Amount        money  `json:"amount"`
+	BankReference string `json:"bankReference"`
+	ValueDate     string `json:"valueDate"`
+}
+
+type paymentReconciliation struct {
+	PaymentType    string          `json:"paymentType"`
+	InvoicedAmount money           `json:"invoicedAmount"`
+	PaidAmount     money           `json:"paidAmount"`
+	Difference     money           `json:"difference"`
+	Status         string          `json:"status"`
+	Payments       []paymentRecord `json:"payments"`
+}
+
+type receivableOffer struct {
+	ContractId          string          `json:"contractId"`
+	InvoiceNumber       string          `json:"invoiceNumber"`
+	SellerId            string          `json:"sellerId"`
+	FaceAmount          money           `json:"faceAmount"`
+	DueDate             string          `json:"dueDate"`
+	OfferDate           string          `json:"offerDate"`
+	Status              string          `json:"status"`
+	Bids                []receivableBid `json:"bids"`
+	AcceptedFinancierId string          `json:"acceptedFinancierId"`
+	AssignmentDate      string          `json:"assignmentDate"`
+}
+
+type receivableKey struct {
+	ContractId    string `json:"contractId"`
+	InvoiceNumber string `json:"invoiceNumber"`
+}
+
+type receivableBid struct {
+	FinancierId   string  `json:"financierId"`
+	DiscountRate  float64 `json:"discountRate"`
+	AdvanceAmount money   `json:"advanceAmount"`
+	BidDate       string  `json:"bidDate"`
+}
+
+type financingRegistration struct {
+	DocumentHash      string             `json:"documentHash"`
+	DocumentType      string             `json:"documentType"`
+	FinancierId       string             `json:"financierId"`
+	Status            string             `json:"status"`
+	RegisteredDate    string             `json:"registeredDate"`
+	ReleasedDate      string             `json:"releasedDate"`
+	DuplicateAttempts []financingAttempt `json:"duplicateAttempts"`
+}
+
+type financingAttempt struct {
+	FinancierId string `json:"financierId"`
+	AttemptDate string `json:"attemptDate"`
+}
+
+type financingCheck struct {
+	DocumentHash string `json:"documentHash"`
+	DocumentType string `json:"documentType"`
+	Status       string `json:"status"`
+	Owned        bool   `json:"owned"`
+}
+
+type creditLimit struct {
+	ClientId    string `json:"clientId"`
+	Limit       money  `json:"limit"`
+	UpdatedDate string `json:"updatedDate"`
+}
+
+type creditExposure struct {
+	LCNumber   string `json:"lcNumber"`
+	ContractId string `json:"contractId"`
+	ClientId   string `json:"clientId"`
+	Amount     money  `json:"amount"`
+	Status     string `json:"status"`
+	OpenedDate string `json:"openedDate"`
+	ClosedDate string `json:"closedDate"`
+}
+
+type creditUtilisation struct {
+	ClientId  string           `json:"clientId"`
+	Currency  string           `json:"currency"`
+	Limit     *money           `json:"limit,omitempty"`
+	Utilised  money            `json:"utilised"`
+	Available *money           `json:"available,omitempty"`
+	Exposures []creditExposure `json:"exposures"`
+}
+
+type taxRateTable struct {
+	Country     string         `json:"country"`
+	TaxRates    []categoryRate `json:"taxRates"`
+	DutyRates   []dutyRate     `json:"dutyRates"`
+	UpdatedBy   string         `json:"updatedBy"`
+	UpdatedDate string         `json:"updatedDate"`
+}
+
+type categoryRate struct {
+	TaxCategory string  `json:"taxCategory"`
+	Rate        float64 `json:"rate"`
+}
+
+type dutyRate struct {
+	HSCode string  `json:"hsCode"`
+	Rate   float64 `json:"rate"`
+}
+
+type contractTaxes struct {
+	Country    string    `json:"country"`
+	Lines      []lineTax `json:"lines"`
+	TaxAmount  money     `json:"taxAmount"`
+	DutyAmount money     `json:"dutyAmount"`
+}
+
+type lineTax struct {
+	LineNumber  int     `json:"lineNumber"`
+	HSCode      string  `json:"hsCode"`
+	TaxCategory string  `json:"taxCategory"`
+	TaxRate     float64 `json:"taxRate"`
+	TaxAmount   money   `json:"taxAmount"`
+	DutyRate    float64 `json:"dutyRate"`
+	DutyAmount  money   `json:"dutyAmount"`
+}
+
+type lateInterestTerms struct {
+	AnnualRate float64 `json:"annualRate"`
+	DayCount   string  `json:"dayCount"`
+}
+
+type interestAccrual struct {
+	ContractId      string           `json:"contractId"`
+	PaymentDueDate  string           `json:"paymentDueDate"`
+	AsOfDate        string           `json:"asOfDate"`
+	AnnualRate      float64          `json:"annualRate"`
+	DayCount        string           `json:"dayCount"`
+	Principal       money            `json:"principal"`
+	Periods         []interestPeriod `json:"periods"`
+	AccruedInterest money            `json:"accruedInterest"`
+}
+
+type interestPeriod struct {
+	FromDate    string `json:"fromDate"`
+	ToDate      string `json:"toDate"`
+	Days        int    `json:"days"`
+	Outstanding money  `json:"outstanding"`
+	Interest    money  `json:"interest"`
+}
+
+type feeSchedule struct {
+	BankId      string    `json:"bankId"`
+	Version     int       `json:"version"`
+	Fees        []feeRule `json:"fees"`
+	UpdatedDate string    `json:"updatedDate"`
+}
+
+type feeRule struct {
+	Event         string  `json:"event"`
+	Currency      string  `json:"currency"`
+	Percentage    float64 `json:"percentage"`
+	MinimumAmount money   `json:"minimumAmount"`
+	FixedAmount   money   `json:"fixedAmount"`
+}
+
+type feeCharge struct {
+	ContractId  string `json:"contractId"`
+	BankId      string `json:"bankId"`
+	Event       string `json:"event"`
+	Reference   string `json:"reference"`
+	BaseAmount  money  `json:"baseAmount"`
+	Amount      money  `json:"amount"`
+	ChargedTo   string `json:"chargedTo"`
+	PayerId     string `json:"payerId"`
+	ChargedDate string `json:"chargedDate"`
+}
+
+type feeStatement struct {
+	ContractId string      `json:"contractId"`
+	UserId     string      `json:"userId"`
+	Charges    []feeCharge `json:"charges"`
+	Total      []money     `json:"total"`
+}
+
+type guarantee struct {
+	GuaranteeNumber string               `json:"guaranteeNumber"`
+	GuaranteeType   string               `json:"guaranteeType"`
+	ContractId      string               `json:"contractId"`
+	IssuingBank     string               `json:"issuingBank"`
+	Applicant       string               `json:"applicant"`
+	Beneficiary     string               `json:"beneficiary"`
+	Amount          money                `json:"amount"`
+	ClaimedAmount   money                `json:"claimedAmount"`
+	ValidFrom       string               `json:"validFrom"`
+	ExpiryDate      string               `json:"expiryDate"`
+	Status          string               `json:"status"`
+	IssueDate       string               `json:"issueDate"`
+	ReleaseDate     string               `json:"releaseDate"`
+	Amendments      []guaranteeAmendment `json:"amendments"`
+	Claims          []guaranteeClaim     `json:"claims"`
+}
+
+type guaranteeAmendment struct {
+	AmendmentNumber int    `json:"amendmentNumber"`
+	Amount          money  `json:"amount"`
+	ExpiryDate      string `json:"expiryDate"`
+	Status          string `json:"status"`
+	RequestDate     string `json:"requestDate"`
+	ResponseDate    string `json:"responseDate"`
+}
+
+type guaranteeClaim struct {
+	ClaimNumber int    `json:"claimNumber"`
+	Amount      money  `json:"amount"`
+	Statement   string `json:"statement"`
+	ClaimDate   string `json:"claimDate"`
+}
+
+type trackingEvent struct {
+	EventNumber int    `json:"eventNumber"`
+	ContractId  string `json:"contractId"`
+	EventType   string `json:"eventType"`
+	Location    string `json:"location"`
+	Timestamp   string `json:"timestamp"`
+	Description string `json:"description"`
+	PostedBy    string `json:"postedBy"`
+	PostedDate  string `json:"postedDate"`
+}
+
+type billOfLading struct {
+	BLNumber         string          `json:"blNumber"`
+	ContractId       string          `json:"contractId"`
+	Carrier          string          `json:"carrier"`
+	Shipper          string          `json:"shipper"`
+	Consignee        string          `json:"consignee"`
+	PortOfLoading    string          `json:"portOfLoading"`
+	PortOfDischarge  string          `json:"portOfDischarge"`
+	VesselName       string          `json:"vesselName"`
+	GoodsDescription string          `json:"goodsDescription"`
+	IssueDate        string          `json:"issueDate"`
+	Holder           string          `json:"holder"`
+	Status           string          `json:"status"`
+	Endorsements     []blEndorsement `json:"endorsements"`
+	SurrenderDate    string          `json:"surrenderDate"`
+}
+
+type blEndorsement struct {
+	EndorsementNumber int    `json:"endorsementNumber"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+	EndorsementDate   string `json:"endorsementDate"`
+}
+
+type incotermRule struct {
+	Code               string `json:"code"`
+	Name               string `json:"name"`
+	SeaOnly            bool   `json:"seaOnly"`
+	RiskTransferStatus string `json:"riskTransferStatus"`
+	CostTransferStatus string `json:"costTransferStatus"`
+	InsuranceBy        string `json:"insuranceBy"`
+	ExportClearanceBy  string `json:"exportClearanceBy"`
+	ImportClearanceBy  string `json:"importClearanceBy"`
+}
+
+type riskTransfer struct {
+	incotermRule
+	RiskTransferredDate string `json:"riskTransferredDate"`
+}
+
+type tradeDocument struct {
+	DocumentType  string `json:"documentType"`
+	SubmittedBy   string `json:"submittedBy"`
+	SubmittedDate string `json:"submittedDate"`
+}
+
+type conditionRule struct {
+	Metric string  `json:"metric"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+type sensorDevice struct {
+	SensorId       string `json:"sensorId"`
+	ContractId     string `json:"contractId"`
+	RegisteredBy   string `json:"registeredBy"`
+	PublicKey      string `json:"publicKey"`
+	LastSequence   int    `json:"lastSequence"`
+	RegisteredDate string `json:"registeredDate"`
+}
+
+type conditionReading struct {
+	SensorId     string            `json:"sensorId"`
+	ContractId   string            `json:"contractId"`
+	Sequence     int               `json:"sequence"`
+	Timestamp    string            `json:"timestamp"`
+	Temperature  *float64          `json:"temperature,omitempty"`
+	Humidity     *float64          `json:"humidity,omitempty"`
+	Signature    string            `json:"signature"`
+	Breaches     []conditionBreach `json:"breaches"`
+	RecordedDate string            `json:"recordedDate"`
+}
+
+type conditionBreach struct {
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+type dispute struct {
+	DisputeNumber int                `json:"disputeNumber"`
+	ContractId    string             `json:"contractId"`
+	RaisedBy      string             `json:"raisedBy"`
+	Reason        string             `json:"reason"`
+	Status        string             `json:"status"`
+	RaisedDate    string             `json:"raisedDate"`
+	Evidence      []conditionReading `json:"evidence"`
+}
+
+type transportLeg struct {
+	LegNumber     int    `json:"legNumber"`
+	Mode          string `json:"mode"`
+	Transporter   user   `json:"transporter"`
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departureDate"`
+	ArrivalDate   string `json:"arrivalDate"`
+	Status        string `json:"status"`
+	StartedDate   string `json:"startedDate"`
+	CompletedDate string `json:"completedDate"`
+	HandedOverTo  string `json:"handedOverTo"`
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
@@ -35,6 +41,253 @@ func createDatabase(stub shim.ChaincodeStubInterface, args []string) (bool, erro
 		return false, errors.New("Failed creating userDetails table.")
 	}
 
+	err = stub.CreateTable("userRoleDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "userId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "roleList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating userRoleDetails table.")
+	}
+
+	err = stub.CreateTable("watchListDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "version", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "watchListObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating watchListDetails table.")
+	}
+
+	err = stub.CreateTable("screeningDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "screeningList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating screeningDetails table.")
+	}
+
+	err = stub.CreateTable("configurationDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "configKey", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "configurationList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating configurationDetails table.")
+	}
+
+	//Pricing is kept out of contractDetails and only served to the trading parties
+	err = stub.CreateTable("contractPricingDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "pricingObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating contractPricingDetails table.")
+	}
+
+	err = stub.CreateTable("publicKeyDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "userId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "publicKeyList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating publicKeyDetails table.")
+	}
+
+	err = stub.CreateTable("signatureDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "signatureList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating signatureDetails table.")
+	}
+
+	err = stub.CreateTable("contractVersionDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "contractVersion", Type: shim.ColumnDefinition_INT32, Key: true},
+		&shim.ColumnDefinition{Name: "contractObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating contractVersionDetails table.")
+	}
+
+	err = stub.CreateTable("fxRateDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "currencyPair", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "rateDate", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "rateObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating fxRateDetails table.")
+	}
+
+	err = stub.CreateTable("letterOfCreditDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "lcNumber", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "lcObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating letterOfCreditDetails table.")
+	}
+
+	err = stub.CreateTable("penaltyRuleDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "organisationId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "ruleSetList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating penaltyRuleDetails table.")
+	}
+
+	err = stub.CreateTable("penaltyDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "penaltyList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating penaltyDetails table.")
+	}
+
+	err = stub.CreateTable("invoiceDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "sellerId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "invoiceNumber", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "invoiceObject", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating invoiceDetails table.")
+	}
+
+	err = stub.CreateTable("paymentScheduleDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "milestoneList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating paymentScheduleDetails table.")
+	}
+
+	err = stub.CreateTable("paymentDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "paymentList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating paymentDetails table.")
+	}
+
+	err = stub.CreateTable("receivableDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "invoiceNumber", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "receivableOffer", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating receivableDetails table.")
+	}
+
+	err = stub.CreateTable("openReceivableDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "key", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "receivableList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating openReceivableDetails table.")
+	}
+
+	err = stub.CreateTable("financingRegistryDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "documentHash", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "registration", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating financingRegistryDetails table.")
+	}
+
+	err = stub.CreateTable("creditLimitDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "bankId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "limitList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating creditLimitDetails table.")
+	}
+
+	err = stub.CreateTable("creditExposureDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "bankId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "exposureList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating creditExposureDetails table.")
+	}
+
+	err = stub.CreateTable("taxRateDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "country", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "rateTable", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating taxRateDetails table.")
+	}
+
+	err = stub.CreateTable("settlementAccountDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "ownerId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "balanceList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating settlementAccountDetails table.")
+	}
+
+	err = stub.CreateTable("feeScheduleDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "bankId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "feeSchedule", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating feeScheduleDetails table.")
+	}
+
+	err = stub.CreateTable("feeDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "feeList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating feeDetails table.")
+	}
+
+	err = stub.CreateTable("guaranteeDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "guaranteeNumber", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "guarantee", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating guaranteeDetails table.")
+	}
+
+	err = stub.CreateTable("trackingDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "eventList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating trackingDetails table.")
+	}
+
+	err = stub.CreateTable("billOfLadingDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "blNumber", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "billOfLading", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating billOfLadingDetails table.")
+	}
+
+	err = stub.CreateTable("sensorDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "sensorId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "sensor", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating sensorDetails table.")
+	}
+
+	err = stub.CreateTable("conditionReadingDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "sensorId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "sequence", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "reading", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating conditionReadingDetails table.")
+	}
+
+	err = stub.CreateTable("disputeDetails", []*shim.ColumnDefinition{
+		&shim.ColumnDefinition{Name: "contractId", Type: shim.ColumnDefinition_STRING, Key: true},
+		&shim.ColumnDefinition{Name: "disputeList", Type: shim.ColumnDefinition_BYTES, Key: false},
+	})
+	if err != nil {
+		return false, errors.New("Failed creating disputeDetails table.")
+	}
+
 	return true, nil
 
 }
@@ -51,9 +304,17 @@ func insertUserBlankRecord(stub shim.ChaincodeStubInterface, userId string) (boo
 
 }
 
-func insertContractDetails(stub shim.ChaincodeStubInterface, contractDetails contract) (bool, error) {
+func insertContractDetails(stub shim.ChaincodeStubInterface, contractDetails contract, pricingSalt string) (bool, error) {
 	var err error
 	var ok bool
+
+	contractDetails.ContractVersion = 1
+	contractDetails, pricing := splitContractPricing(contractDetails, pricingSalt)
+	ok = updateContractPricing(stub, pricing)
+	if !ok {
+		return ok, errors.New("Error in saving contract pricing")
+	}
+
 	jsonAsBytes, _ := json.Marshal(contractDetails)
 	ok, err = stub.InsertRow("contractDetails", shim.Row{
 		Columns: []*shim.Column{
@@ -64,6 +325,96 @@ func insertContractDetails(stub shim.ChaincodeStubInterface, contractDetails con
 	return ok, err
 }
 
+// newPricingSalt is only for contracts saved before pricing had its own row. Anyone who reads the ledger can
+// recompute it, so their pricing hash does not hide the prices
+func newPricingSalt(stub shim.ChaincodeStubInterface, contractId string) string {
+	salt := sha256.Sum256([]byte(stub.GetTxID() + contractId))
+	return hex.EncodeToString(salt[:16])
+}
+
+func hashContractPricing(pricing contractPricing) string {
+	salt := pricing.Salt
+	pricing.Salt = ""
+	jsonAsBytes, _ := json.Marshal(pricing)
+	hash := sha256.Sum256(append([]byte(salt), jsonAsBytes...))
+	return hex.EncodeToString(hash[:])
+}
+
+// splitContractPricing moves the pricing fields into their own row and leaves their hash on the contract
+func splitContractPricing(contractDetails contract, salt string) (contract, contractPricing) {
+	var pricing contractPricing
+
+	pricing.ContractId = contractDetails.ContractId
+	pricing.Salt = salt
+	pricing.TotalTradeAmount = contractDetails.TotalTradeAmount
+	pricing.DiscountedAmount = contractDetails.DiscountedAmount
+	pricing.DiscountPercentage = contractDetails.DiscountPercentage
+	pricing.Taxes = contractDetails.Taxes
+
+	var tradeDetails []product
+	for _, element := range contractDetails.TradeDetails {
+		pricing.TradeDetails = append(pricing.TradeDetails, productPricing{element.ProductName, element.ProductPrice, element.TotalAmount})
+		element.ProductPrice = ""
+		element.TotalAmount = ""
+		tradeDetails = append(tradeDetails, element)
+	}
+
+	contractDetails.TradeDetails = tradeDetails
+	contractDetails.TotalTradeAmount = money{}
+	contractDetails.DiscountedAmount = money{}
+	contractDetails.DiscountPercentage = 0
+	contractDetails.Taxes = nil
+	contractDetails.PricingHash = hashContractPricing(pricing)
+
+	return contractDetails, pricing
+}
+
+func mergeContractPricing(contractDetails contract, pricing contractPricing) contract {
+	contractDetails.TotalTradeAmount = pricing.TotalTradeAmount
+	contractDetails.DiscountedAmount = pricing.DiscountedAmount
+	contractDetails.DiscountPercentage = pricing.DiscountPercentage
+	contractDetails.Taxes = pricing.Taxes
+
+	var tradeDetails []product
+	for i, element := range contractDetails.TradeDetails {
+		if i < len(pricing.TradeDetails) {
+			element.ProductPrice = pricing.TradeDetails[i].ProductPrice
+			element.TotalAmount = pricing.TradeDetails[i].TotalAmount
+		}
+		tradeDetails = append(tradeDetails, element)
+	}
+	contractDetails.TradeDetails = tradeDetails
+
+	return contractDetails
+}
+
+func getContractPricingDetails(stub shim.ChaincodeStubInterface, contractId string) (contractPricing, bool) {
+	var columns []shim.Column
+	var pricing contractPricing
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("contractPricingDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return pricing, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &pricing)
+	return pricing, true
+}
+
+func updateContractPricing(stub shim.ChaincodeStubInterface, pricing contractPricing) bool {
+	JsonAsBytes, _ := json.Marshal(pricing)
+
+	return replaceOrInsertRow(stub, "contractPricingDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: pricing.ContractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
 func insertAttachmentDetails(stub shim.ChaincodeStubInterface, contractID string, attachmentName string, documentBlob string) (bool, error) {
 	var err error
 	var ok bool
@@ -93,6 +444,21 @@ func getContractDetails(stub shim.ChaincodeStubInterface, contractId string) (co
 	contractAsBytes := row.Columns[1].GetBytes()
 	json.Unmarshal(contractAsBytes, &contractList)
 
+	//Merge pricing only when it still matches the hash on the contract
+	pricing, found := getContractPricingDetails(stub, contractId)
+	if found && hashContractPricing(pricing) == contractList.PricingHash {
+		contractList = mergeContractPricing(contractList, pricing)
+	}
+
+	//Rows written before money carried a currency hold plain numbers
+	currency := contractList.TradeConditions.Currency
+	if amount, err := contractList.TotalTradeAmount.inCurrency(currency); err == nil {
+		contractList.TotalTradeAmount = amount
+	}
+	if amount, err := contractList.DiscountedAmount.inCurrency(currency); err == nil {
+		contractList.DiscountedAmount = amount
+	}
+
 	return contractList, nil
 
 }
@@ -168,6 +534,19 @@ func insertUserContractList(stub shim.ChaincodeStubInterface, userId string, con
 }
 
 func updateContractListByContractID(stub shim.ChaincodeStubInterface, contractId string, contractList contract) bool {
+	salt := newPricingSalt(stub, contractId)
+	existingPricing, found := getContractPricingDetails(stub, contractId)
+	if found {
+		salt = existingPricing.Salt
+	}
+
+	//Every write is a new contract version
+	contractList.ContractVersion++
+	contractList, pricing := splitContractPricing(contractList, salt)
+	if !updateContractPricing(stub, pricing) {
+		return false
+	}
+
 	JsonAsBytes, _ := json.Marshal(contractList)
 
 	ok, err := stub.ReplaceRow("contractDetails", shim.Row{
@@ -184,6 +563,911 @@ func updateContractListByContractID(stub shim.ChaincodeStubInterface, contractId
 	return true
 }
 
+func replaceOrInsertRow(stub shim.ChaincodeStubInterface, tableName string, row shim.Row) bool {
+	ok, err := stub.ReplaceRow(tableName, row)
+	if ok {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+
+	ok, err = stub.InsertRow(tableName, row)
+	if !ok || err != nil {
+		return false
+	}
+	return true
+}
+
+func getUserRoles(stub shim.ChaincodeStubInterface, userId string) []string {
+	var columns []shim.Column
+	var roleList []string
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: userId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("userRoleDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return roleList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &roleList)
+	return roleList
+}
+
+func updateUserRoles(stub shim.ChaincodeStubInterface, userId string, roleList []string) bool {
+	JsonAsBytes, _ := json.Marshal(roleList)
+
+	return replaceOrInsertRow(stub, "userRoleDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: userId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getWatchListDetails(stub shim.ChaincodeStubInterface, version string) (watchList, bool) {
+	var columns []shim.Column
+	var watchListDetails watchList
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: version}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("watchListDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return watchListDetails, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &watchListDetails)
+	return watchListDetails, true
+}
+
+func updateWatchListDetails(stub shim.ChaincodeStubInterface, version string, watchListDetails watchList) bool {
+	JsonAsBytes, _ := json.Marshal(watchListDetails)
+
+	return replaceOrInsertRow(stub, "watchListDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: version}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getScreeningList(stub shim.ChaincodeStubInterface, contractId string) []screeningResult {
+	var columns []shim.Column
+	var screeningList []screeningResult
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("screeningDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return screeningList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &screeningList)
+	return screeningList
+}
+
+func updateScreeningList(stub shim.ChaincodeStubInterface, contractId string, screeningList []screeningResult) bool {
+	JsonAsBytes, _ := json.Marshal(screeningList)
+
+	return replaceOrInsertRow(stub, "screeningDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getConfigurationHistory(stub shim.ChaincodeStubInterface) []configuration {
+	var columns []shim.Column
+	var configurationList []configuration
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: "history"}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("configurationDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return configurationList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &configurationList)
+	return configurationList
+}
+
+func updateConfigurationHistory(stub shim.ChaincodeStubInterface, configurationList []configuration) bool {
+	JsonAsBytes, _ := json.Marshal(configurationList)
+
+	return replaceOrInsertRow(stub, "configurationDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: "history"}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getPublicKeyList(stub shim.ChaincodeStubInterface, userId string) []publicKey {
+	var columns []shim.Column
+	var publicKeyList []publicKey
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: userId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("publicKeyDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return publicKeyList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &publicKeyList)
+	return publicKeyList
+}
+
+func updatePublicKeyList(stub shim.ChaincodeStubInterface, userId string, publicKeyList []publicKey) bool {
+	JsonAsBytes, _ := json.Marshal(publicKeyList)
+
+	return replaceOrInsertRow(stub, "publicKeyDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: userId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getSignatureList(stub shim.ChaincodeStubInterface, contractId string) []contractSignature {
+	var columns []shim.Column
+	var signatureList []contractSignature
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("signatureDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return signatureList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &signatureList)
+	return signatureList
+}
+
+func updateSignatureList(stub shim.ChaincodeStubInterface, contractId string, signatureList []contractSignature) bool {
+	JsonAsBytes, _ := json.Marshal(signatureList)
+
+	return replaceOrInsertRow(stub, "signatureDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func insertContractVersion(stub shim.ChaincodeStubInterface, contractId string, contractVersion int, contractAsBytes []byte) bool {
+	return replaceOrInsertRow(stub, "contractVersionDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Int32{Int32: int32(contractVersion)}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: contractAsBytes}},
+		},
+	})
+}
+
+func getContractVersion(stub shim.ChaincodeStubInterface, contractId string, contractVersion int) ([]byte, bool) {
+	var columns []shim.Column
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	col2 := shim.Column{Value: &shim.Column_Int32{Int32: int32(contractVersion)}}
+	columns = append(columns, col1)
+	columns = append(columns, col2)
+
+	row, err := stub.GetRow("contractVersionDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return nil, false
+	}
+
+	return row.Columns[2].GetBytes(), true
+}
+
+// getLatestFxRateDetails returns the pair's most recent rate on or before rateDate
+func getLatestFxRateDetails(stub shim.ChaincodeStubInterface, fromCurrency string, toCurrency string, rateDate string) (fxRate, bool) {
+	var columns []shim.Column
+	var latest fxRate
+	found := false
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: fromCurrency + "/" + toCurrency}}
+	columns = append(columns, col1)
+
+	rowChannel, err := stub.GetRows("fxRateDetails", columns)
+	if err != nil {
+		return latest, false
+	}
+
+	for row := range rowChannel {
+		var rate fxRate
+		json.Unmarshal(row.Columns[2].GetBytes(), &rate)
+		if rate.RateDate <= rateDate && (!found || rate.RateDate > latest.RateDate) {
+			latest = rate
+			found = true
+		}
+	}
+	return latest, found
+}
+
+func updateFxRateDetails(stub shim.ChaincodeStubInterface, rate fxRate) bool {
+	JsonAsBytes, _ := json.Marshal(rate)
+
+	return replaceOrInsertRow(stub, "fxRateDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: rate.FromCurrency + "/" + rate.ToCurrency}},
+			&shim.Column{Value: &shim.Column_String_{String_: rate.RateDate}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getLetterOfCreditDetails(stub shim.ChaincodeStubInterface, lcNumber string) (letterOfCredit, bool) {
+	var columns []shim.Column
+	var lcDetails letterOfCredit
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: lcNumber}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("letterOfCreditDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return lcDetails, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &lcDetails)
+	return lcDetails, true
+}
+
+func updateLetterOfCreditDetails(stub shim.ChaincodeStubInterface, lcDetails letterOfCredit) bool {
+	JsonAsBytes, _ := json.Marshal(lcDetails)
+
+	return replaceOrInsertRow(stub, "letterOfCreditDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: lcDetails.LCNumber}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getPenaltyRuleSetList(stub shim.ChaincodeStubInterface, organisationId string) []penaltyRuleSet {
+	var columns []shim.Column
+	var ruleSetList []penaltyRuleSet
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: organisationId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("penaltyRuleDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return ruleSetList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &ruleSetList)
+	return ruleSetList
+}
+
+func updatePenaltyRuleSetList(stub shim.ChaincodeStubInterface, organisationId string, ruleSetList []penaltyRuleSet) bool {
+	JsonAsBytes, _ := json.Marshal(ruleSetList)
+
+	return replaceOrInsertRow(stub, "penaltyRuleDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: organisationId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getPenaltyList(stub shim.ChaincodeStubInterface, contractId string) []penaltyRecord {
+	var columns []shim.Column
+	var penaltyList []penaltyRecord
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("penaltyDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return penaltyList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &penaltyList)
+	return penaltyList
+}
+
+func updatePenaltyList(stub shim.ChaincodeStubInterface, contractId string, penaltyList []penaltyRecord) bool {
+	JsonAsBytes, _ := json.Marshal(penaltyList)
+
+	return replaceOrInsertRow(stub, "penaltyDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func insertInvoiceDetails(stub shim.ChaincodeStubInterface, invoiceDetails invoice) (bool, error) {
+	JsonAsBytes, _ := json.Marshal(invoiceDetails)
+
+	return stub.InsertRow("invoiceDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: invoiceDetails.SellerId}},
+			&shim.Column{Value: &shim.Column_String_{String_: invoiceDetails.InvoiceNumber}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getInvoiceDetails(stub shim.ChaincodeStubInterface, sellerId string, invoiceNumber string) (invoice, bool) {
+	var columns []shim.Column
+	var invoiceDetails invoice
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: sellerId}}
+	col2 := shim.Column{Value: &shim.Column_String_{String_: invoiceNumber}}
+	columns = append(columns, col1)
+	columns = append(columns, col2)
+
+	row, err := stub.GetRow("invoiceDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return invoiceDetails, false
+	}
+
+	json.Unmarshal(row.Columns[2].GetBytes(), &invoiceDetails)
+	return invoiceDetails, true
+}
+
+// countSellerInvoices counts the seller's documents whose number starts with prefix
+func countSellerInvoices(stub shim.ChaincodeStubInterface, sellerId string, prefix string) int {
+	var columns []shim.Column
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: sellerId}}
+	columns = append(columns, col1)
+
+	rowChannel, err := stub.GetRows("invoiceDetails", columns)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for row := range rowChannel {
+		if strings.HasPrefix(row.Columns[1].GetString_(), prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func getMilestoneStatusList(stub shim.ChaincodeStubInterface, contractId string) []milestoneStatus {
+	var columns []shim.Column
+	var milestoneList []milestoneStatus
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("paymentScheduleDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return milestoneList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &milestoneList)
+	return milestoneList
+}
+
+func updateMilestoneStatusList(stub shim.ChaincodeStubInterface, contractId string, milestoneList []milestoneStatus) bool {
+	JsonAsBytes, _ := json.Marshal(milestoneList)
+
+	return replaceOrInsertRow(stub, "paymentScheduleDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getPaymentList(stub shim.ChaincodeStubInterface, contractId string) []paymentRecord {
+	var columns []shim.Column
+	var paymentList []paymentRecord
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("paymentDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return paymentList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &paymentList)
+	return paymentList
+}
+
+func updatePaymentList(stub shim.ChaincodeStubInterface, contractId string, paymentList []paymentRecord) bool {
+	JsonAsBytes, _ := json.Marshal(paymentList)
+
+	return replaceOrInsertRow(stub, "paymentDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getReceivableOffer(stub shim.ChaincodeStubInterface, contractId string, invoiceNumber string) (receivableOffer, bool) {
+	var columns []shim.Column
+	var offer receivableOffer
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+	col2 := shim.Column{Value: &shim.Column_String_{String_: invoiceNumber}}
+	columns = append(columns, col2)
+
+	row, err := stub.GetRow("receivableDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return offer, false
+	}
+
+	json.Unmarshal(row.Columns[2].GetBytes(), &offer)
+	return offer, true
+}
+
+func getContractReceivableOffers(stub shim.ChaincodeStubInterface, contractId string) []receivableOffer {
+	var columns []shim.Column
+	var offerList []receivableOffer
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	rowChannel, err := stub.GetRows("receivableDetails", columns)
+	if err != nil {
+		return offerList
+	}
+
+	for row := range rowChannel {
+		var offer receivableOffer
+		json.Unmarshal(row.Columns[2].GetBytes(), &offer)
+		offerList = append(offerList, offer)
+	}
+	return offerList
+}
+
+func updateReceivableOffer(stub shim.ChaincodeStubInterface, offer receivableOffer) bool {
+	JsonAsBytes, _ := json.Marshal(offer)
+
+	return replaceOrInsertRow(stub, "receivableDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: offer.ContractId}},
+			&shim.Column{Value: &shim.Column_String_{String_: offer.InvoiceNumber}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getOpenReceivableList(stub shim.ChaincodeStubInterface) []receivableKey {
+	var columns []shim.Column
+	var receivableList []receivableKey
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: "open"}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("openReceivableDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return receivableList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &receivableList)
+	return receivableList
+}
+
+func updateOpenReceivableList(stub shim.ChaincodeStubInterface, receivableList []receivableKey) bool {
+	JsonAsBytes, _ := json.Marshal(receivableList)
+
+	return replaceOrInsertRow(stub, "openReceivableDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: "open"}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+// The registry salt shares the table under a key that can never be a hex hash
+func getRegistrySalt(stub shim.ChaincodeStubInterface) string {
+	var columns []shim.Column
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: "registrySalt"}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("financingRegistryDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return ""
+	}
+	return string(row.Columns[1].GetBytes())
+}
+
+func updateRegistrySalt(stub shim.ChaincodeStubInterface, salt string) bool {
+	return replaceOrInsertRow(stub, "financingRegistryDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: "registrySalt"}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: []byte(salt)}},
+		},
+	})
+}
+
+func getFinancingRegistration(stub shim.ChaincodeStubInterface, documentHash string) (financingRegistration, bool) {
+	var columns []shim.Column
+	var registration financingRegistration
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: documentHash}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("financingRegistryDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return registration, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &registration)
+	return registration, true
+}
+
+func updateFinancingRegistration(stub shim.ChaincodeStubInterface, registration financingRegistration) bool {
+	JsonAsBytes, _ := json.Marshal(registration)
+
+	return replaceOrInsertRow(stub, "financingRegistryDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: registration.DocumentHash}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getCreditLimitList(stub shim.ChaincodeStubInterface, bankId string) []creditLimit {
+	var columns []shim.Column
+	var limitList []creditLimit
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: bankId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("creditLimitDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return limitList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &limitList)
+	return limitList
+}
+
+func updateCreditLimitList(stub shim.ChaincodeStubInterface, bankId string, limitList []creditLimit) bool {
+	JsonAsBytes, _ := json.Marshal(limitList)
+
+	return replaceOrInsertRow(stub, "creditLimitDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: bankId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getCreditExposureList(stub shim.ChaincodeStubInterface, bankId string) []creditExposure {
+	var columns []shim.Column
+	var exposureList []creditExposure
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: bankId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("creditExposureDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return exposureList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &exposureList)
+	return exposureList
+}
+
+func updateCreditExposureList(stub shim.ChaincodeStubInterface, bankId string, exposureList []creditExposure) bool {
+	JsonAsBytes, _ := json.Marshal(exposureList)
+
+	return replaceOrInsertRow(stub, "creditExposureDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: bankId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getTaxRateTable(stub shim.ChaincodeStubInterface, country string) (taxRateTable, bool) {
+	var columns []shim.Column
+	var rateTable taxRateTable
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: country}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("taxRateDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return rateTable, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &rateTable)
+	return rateTable, true
+}
+
+func updateTaxRateTable(stub shim.ChaincodeStubInterface, rateTable taxRateTable) bool {
+	JsonAsBytes, _ := json.Marshal(rateTable)
+
+	return replaceOrInsertRow(stub, "taxRateDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: rateTable.Country}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getSettlementBalanceList(stub shim.ChaincodeStubInterface, ownerId string) []money {
+	var columns []shim.Column
+	var balanceList []money
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: ownerId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("settlementAccountDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return balanceList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &balanceList)
+	return balanceList
+}
+
+func updateSettlementBalanceList(stub shim.ChaincodeStubInterface, ownerId string, balanceList []money) bool {
+	JsonAsBytes, _ := json.Marshal(balanceList)
+
+	return replaceOrInsertRow(stub, "settlementAccountDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: ownerId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getFeeSchedule(stub shim.ChaincodeStubInterface, bankId string) (feeSchedule, bool) {
+	var columns []shim.Column
+	var schedule feeSchedule
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: bankId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("feeScheduleDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return schedule, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &schedule)
+	return schedule, true
+}
+
+// updateFeeSchedule keeps the latest schedule under the bank id and every version under bankId#version
+func updateFeeSchedule(stub shim.ChaincodeStubInterface, schedule feeSchedule) bool {
+	JsonAsBytes, _ := json.Marshal(schedule)
+
+	for _, key := range []string{schedule.BankId, schedule.BankId + "#" + strconv.Itoa(schedule.Version)} {
+		ok := replaceOrInsertRow(stub, "feeScheduleDetails", shim.Row{
+			Columns: []*shim.Column{
+				&shim.Column{Value: &shim.Column_String_{String_: key}},
+				&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+			},
+		})
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func getFeeScheduleVersion(stub shim.ChaincodeStubInterface, bankId string, version int) (feeSchedule, bool) {
+	return getFeeSchedule(stub, bankId+"#"+strconv.Itoa(version))
+}
+
+func getFeeList(stub shim.ChaincodeStubInterface, contractId string) []feeCharge {
+	var columns []shim.Column
+	var feeList []feeCharge
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("feeDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return feeList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &feeList)
+	return feeList
+}
+
+func updateFeeList(stub shim.ChaincodeStubInterface, contractId string, feeList []feeCharge) bool {
+	JsonAsBytes, _ := json.Marshal(feeList)
+
+	return replaceOrInsertRow(stub, "feeDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getGuaranteeDetails(stub shim.ChaincodeStubInterface, guaranteeNumber string) (guarantee, bool) {
+	var columns []shim.Column
+	var guaranteeDetails guarantee
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: guaranteeNumber}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("guaranteeDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return guaranteeDetails, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &guaranteeDetails)
+	return guaranteeDetails, true
+}
+
+func updateGuaranteeDetails(stub shim.ChaincodeStubInterface, guaranteeDetails guarantee) bool {
+	JsonAsBytes, _ := json.Marshal(guaranteeDetails)
+
+	return replaceOrInsertRow(stub, "guaranteeDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: guaranteeDetails.GuaranteeNumber}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getTrackingEventList(stub shim.ChaincodeStubInterface, contractId string) []trackingEvent {
+	var columns []shim.Column
+	var eventList []trackingEvent
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("trackingDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return eventList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &eventList)
+	return eventList
+}
+
+func updateTrackingEventList(stub shim.ChaincodeStubInterface, contractId string, eventList []trackingEvent) bool {
+	JsonAsBytes, _ := json.Marshal(eventList)
+
+	return replaceOrInsertRow(stub, "trackingDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getBillOfLadingDetails(stub shim.ChaincodeStubInterface, blNumber string) (billOfLading, bool) {
+	var columns []shim.Column
+	var blDetails billOfLading
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: blNumber}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("billOfLadingDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return blDetails, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &blDetails)
+	return blDetails, true
+}
+
+func updateBillOfLadingDetails(stub shim.ChaincodeStubInterface, blDetails billOfLading) bool {
+	JsonAsBytes, _ := json.Marshal(blDetails)
+
+	return replaceOrInsertRow(stub, "billOfLadingDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: blDetails.BLNumber}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getSensorDetails(stub shim.ChaincodeStubInterface, sensorId string) (sensorDevice, bool) {
+	var columns []shim.Column
+	var sensor sensorDevice
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: sensorId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("sensorDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return sensor, false
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &sensor)
+	return sensor, true
+}
+
+func updateSensorDetails(stub shim.ChaincodeStubInterface, sensor sensorDevice) bool {
+	JsonAsBytes, _ := json.Marshal(sensor)
+
+	return replaceOrInsertRow(stub, "sensorDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: sensor.SensorId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
+func getConditionReadingList(stub shim.ChaincodeStubInterface, contractId string) []conditionReading {
+	var columns []shim.Column
+	var readingList []conditionReading
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	rowChannel, err := stub.GetRows("conditionReadingDetails", columns)
+	if err != nil {
+		return readingList
+	}
+
+	for row := range rowChannel {
+		var reading conditionReading
+		json.Unmarshal(row.Columns[3].GetBytes(), &reading)
+		readingList = append(readingList, reading)
+	}
+	sort.SliceStable(readingList, func(i, j int) bool {
+		if readingList[i].Timestamp != readingList[j].Timestamp {
+			return readingList[i].Timestamp < readingList[j].Timestamp
+		} else if readingList[i].SensorId != readingList[j].SensorId {
+			return readingList[i].SensorId < readingList[j].SensorId
+		}
+		return readingList[i].Sequence < readingList[j].Sequence
+	})
+	return readingList
+}
+
+// insertConditionReading stores each reading in its own row keyed by contract, sensor and sequence
+func insertConditionReading(stub shim.ChaincodeStubInterface, reading conditionReading) bool {
+	JsonAsBytes, _ := json.Marshal(reading)
+
+	ok, err := stub.InsertRow("conditionReadingDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: reading.ContractId}},
+			&shim.Column{Value: &shim.Column_String_{String_: reading.SensorId}},
+			&shim.Column{Value: &shim.Column_String_{String_: fmt.Sprintf("%010d", reading.Sequence)}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+	return ok && err == nil
+}
+
+func getDisputeList(stub shim.ChaincodeStubInterface, contractId string) []dispute {
+	var columns []shim.Column
+	var disputeList []dispute
+
+	col1 := shim.Column{Value: &shim.Column_String_{String_: contractId}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow("disputeDetails", columns)
+	if err != nil || len(row.Columns) == 0 {
+		return disputeList
+	}
+
+	json.Unmarshal(row.Columns[1].GetBytes(), &disputeList)
+	return disputeList
+}
+
+func updateDisputeList(stub shim.ChaincodeStubInterface, contractId string, disputeList []dispute) bool {
+	JsonAsBytes, _ := json.Marshal(disputeList)
+
+	return replaceOrInsertRow(stub, "disputeDetails", shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: contractId}},
+			&shim.Column{Value: &shim.Column_Bytes{Bytes: JsonAsBytes}},
+		},
+	})
+}
+
 /*func GetUserSpecificContractList(stub shim.ChaincodeStubInterface, UserId string) ([]string, error) {
 	var columns []shim.Column
 	var ContractList []string
@@ -1,6 +1,13 @@
 package main
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
 
 var Contract_Created = "Contract Created"
 var Contract_Accepted = "Contract Accepted"
@@ -13,6 +20,7 @@ var Invoice_Created = "Invoice Created"
 var Payment_Completed_to_Seller_Bank = "Payment Completed to Seller Bank"
 var Payment_Completed_to_Seller = "Payment Completed to Seller"
 var Contract_Completed = "Contract Completed"
+var Contract_Blocked = "Contract Blocked"
 
 //Payment Condotions
 var Max_Days_PaymentDuration = 30
@@ -22,6 +30,194 @@ var Max_Days_TransportDuration = 20
 var Min_Days_DeliveryDuration = 15
 var Max_Days_DeliveryDuration = 30
 
+// defaultConfiguration is version 0, used until an admin stores a configuration on the ledger
+func defaultConfiguration() configuration {
+	return configuration{
+		Version:                  0,
+		MaxDaysPaymentDuration:   Max_Days_PaymentDuration,
+		MinDaysPaymentDuration:   Min_Days_PaymentDuration,
+		MinDaysTransportDuration: Min_Days_TransportDuration,
+		MaxDaysTransportDuration: Max_Days_TransportDuration,
+		MinDaysDeliveryDuration:  Min_Days_DeliveryDuration,
+		MaxDaysDeliveryDuration:  Max_Days_DeliveryDuration,
+		LatestContractsCount:     nc,
+		LateDeliveryDiscountTiers: []discountTier{
+			{MinDays: 1, MaxDays: 5, Percentage: 5},
+			{MinDays: 6, MaxDays: 15, Percentage: 10},
+			{MinDays: 16, MaxDays: 0, Percentage: 20},
+		},
+	}
+}
+
+//Transitions that need the acting party's signature
+var Signature_Required_Status = map[string]bool{
+	Contract_Accepted: true,
+	LC_Approved:       true,
+}
+
+//Letter of Credit Statuses
+var LC_Status_Issued = "Issued"
+var LC_Status_Advised = "Advised"
+var LC_Status_Expired = "Expired"
+
+//Amendment Statuses
+var Amendment_Pending = "Pending"
+var Amendment_Accepted = "Accepted"
+var Amendment_Rejected = "Rejected"
+
+//Penalty Rule Types
+var Rule_Tiered = "tiered"
+var Rule_PerDay = "perDay"
+var Rule_EarlyBonus = "earlyBonus"
+
+//Invoice Types
+var Invoice_Type_Invoice = "Invoice"
+var Invoice_Type_CreditNote = "CreditNote"
+
+//Milestone Statuses
+var Milestone_Scheduled = "Scheduled"
+var Milestone_Due = "Due"
+var Milestone_PartiallyPaid = "Partially Paid"
+var Milestone_Paid = "Paid"
+
+//Payment Reconciliation Results
+var Reconciliation_Matched = "Matched"
+var Reconciliation_Underpaid = "Underpaid"
+var Reconciliation_Overpaid = "Overpaid"
+var Reconciliation_Missing = "Missing"
+
+//Receivable Offer Statuses
+var Receivable_Open = "Open"
+var Receivable_Assigned = "Assigned"
+
+//Financed Document Types
+var Document_Invoice = "invoice"
+var Document_BillOfLading = "billOfLading"
+
+//Financing Registry Statuses
+var Financing_Active = "Active"
+var Financing_Released = "Released"
+var Financing_Duplicate = "Duplicate"
+var Financing_Free = "Free"
+
+//Credit Exposure Statuses
+var Exposure_Open = "Open"
+var Exposure_Settled = "Settled"
+var Exposure_Released = "Released"
+
+//Interest Day Count Conventions
+var DayCount_Act360 = "ACT/360"
+var DayCount_Act365 = "ACT/365"
+var DayCount_30E360 = "30E/360"
+
+//Bank Fee Events
+var Fee_LCIssuance = "lcIssuance"
+var Fee_LCAdvising = "lcAdvising"
+var Fee_LCAmendment = "lcAmendment"
+var Fee_Payment = "payment"
+
+//Bank Charges Clauses
+var Charges_Our = "OUR"
+var Charges_Ben = "BEN"
+var Charges_Sha = "SHA"
+
+//Guarantee Types
+var Guarantee_Performance = "performance"
+var Guarantee_AdvancePayment = "advancePayment"
+var Guarantee_Standby = "standbyLC"
+
+//Guarantee Statuses
+var Guarantee_Status_Issued = "Issued"
+var Guarantee_Status_Exhausted = "Exhausted"
+var Guarantee_Status_Released = "Released"
+var Guarantee_Status_Expired = "Expired"
+
+//Trade Document Types
+var Document_Insurance = "insuranceCertificate"
+var Document_ExportDeclaration = "exportDeclaration"
+var Document_ImportDeclaration = "importDeclaration"
+
+//Condition Monitoring Metrics
+var Metric_Temperature = "temperature"
+var Metric_Humidity = "humidity"
+
+//Dispute Statuses
+var Dispute_Open = "Open"
+
+//Transport Leg Modes and Statuses
+var Transport_Modes = map[string]bool{
+	"road": true,
+	"rail": true,
+	"sea":  true,
+	"air":  true,
+}
+
+var Leg_Status_Pending = "Pending"
+var Leg_Status_InTransit = "In Transit"
+var Leg_Status_Completed = "Completed"
+
+//Incoterms 2020, risk and cost pass to the buyer at the given contract status
+var Incoterms = map[string]incotermRule{
+	"EXW": {"EXW", "Ex Works", false, Ready_For_Shipment, Ready_For_Shipment, "", Party_Buyer, Party_Buyer},
+	"FCA": {"FCA", "Free Carrier", false, Shipment_Inprogress, Shipment_Inprogress, "", Party_Seller, Party_Buyer},
+	"CPT": {"CPT", "Carriage Paid To", false, Shipment_Inprogress, Shipment_Delivered, "", Party_Seller, Party_Buyer},
+	"CIP": {"CIP", "Carriage and Insurance Paid To", false, Shipment_Inprogress, Shipment_Delivered, Party_Seller, Party_Seller, Party_Buyer},
+	"DAP": {"DAP", "Delivered at Place", false, Shipment_Delivered, Shipment_Delivered, "", Party_Seller, Party_Buyer},
+	"DPU": {"DPU", "Delivered at Place Unloaded", false, Shipment_Delivered, Shipment_Delivered, "", Party_Seller, Party_Buyer},
+	"DDP": {"DDP", "Delivered Duty Paid", false, Shipment_Delivered, Shipment_Delivered, "", Party_Seller, Party_Seller},
+	"FAS": {"FAS", "Free Alongside Ship", true, Shipment_Inprogress, Shipment_Inprogress, "", Party_Seller, Party_Buyer},
+	"FOB": {"FOB", "Free On Board", true, Shipment_Inprogress, Shipment_Inprogress, "", Party_Seller, Party_Buyer},
+	"CFR": {"CFR", "Cost and Freight", true, Shipment_Inprogress, Shipment_Delivered, "", Party_Seller, Party_Buyer},
+	"CIF": {"CIF", "Cost Insurance and Freight", true, Shipment_Inprogress, Shipment_Delivered, Party_Seller, Party_Seller, Party_Buyer},
+}
+
+//Bill of Lading Statuses
+var BL_Status_Issued = "Issued"
+var BL_Status_Surrendered = "Surrendered"
+
+//Shipment Tracking Event Types
+var Tracking_Event_Types = map[string]bool{
+	"pickedUp":       true,
+	"departed":       true,
+	"inTransit":      true,
+	"arrived":        true,
+	"customsHold":    true,
+	"customsCleared": true,
+	"outForDelivery": true,
+	"delivered":      true,
+	"exception":      true,
+}
+
+//Contract Party Roles
+var Party_Seller = "seller"
+var Party_SellerBank = "sellerbank"
+var Party_Buyer = "buyer"
+var Party_BuyerBank = "buyerbank"
+var Party_Transporter = "transporter"
+var Party_LegTransporter = "legtransporter"
+
+var Party_Roles = []string{Party_Seller, Party_SellerBank, Party_Buyer, Party_BuyerBank, Party_Transporter}
+
+//User Roles
+var Role_Admin = "admin"
+var Role_Compliance = "compliance"
+var Role_RateProvider = "rateprovider"
+var Role_Financier = "financier"
+var Role_SettlementAgent = "settlementagent"
+var Role_Bank = "bank"
+
+//Screening Results
+var Screening_Clear = "Clear"
+var Screening_Review = "Review"
+var Screening_Blocked = "Blocked"
+
+//Watch List Entry Types and Actions
+var WatchList_Name = "name"
+var WatchList_Country = "country"
+var WatchList_Identifier = "identifier"
+var WatchList_Action_Block = "block"
+var WatchList_Action_Review = "review"
+
 func mapping_status(contract_status string) string {
 	category := map[string]string{
 		"Contract Created":                 "Contract",
@@ -35,6 +231,7 @@ func mapping_status(contract_status string) string {
 		"Payment Completed to Seller":      "Payment",
 		"Payment Completed to Seller Bank": "Payment",
 		"Contract Completed":               "Completed",
+		"Contract Blocked":                 "Contract",
 	}
 	category_status := category[contract_status]
 	return category_status
@@ -56,3 +253,176 @@ func DiffDays(year2, month2, day2, year1, month1, day1 int) int {
 	} */
 	return diff
 }
+
+// ISO 4217 minor units of the supported currencies
+var Currency_Minor_Units = map[string]int{
+	"AED": 2, "AUD": 2, "BDT": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "DKK": 2, "EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "IDR": 2, "INR": 2,
+	"IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KES": 2, "KRW": 0, "KWD": 3, "LKR": 2,
+	"LYD": 3, "MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2,
+	"PKR": 2, "QAR": 2, "RUB": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"TRY": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// money is an amount held in the minor units of its currency
+type money struct {
+	Minor    int64
+	Currency string
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func isSupportedCurrency(currency string) bool {
+	_, ok := Currency_Minor_Units[currency]
+	return ok
+}
+
+func minorUnits(currency string) int {
+	units, ok := Currency_Minor_Units[currency]
+	if !ok {
+		return 2
+	}
+	return units
+}
+
+func minorUnitScale(currency string) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minorUnits(currency))), nil)
+	return new(big.Rat).SetInt(scale)
+}
+
+func parseDecimal(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, "/eE") {
+		return nil, errors.New("Invalid decimal " + value)
+	}
+	decimal, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, errors.New("Invalid decimal " + value)
+	}
+	return decimal, nil
+}
+
+func formatDecimal(value *big.Rat) string {
+	decimal := value.FloatString(6)
+	decimal = strings.TrimRight(decimal, "0")
+	return strings.TrimSuffix(decimal, ".")
+}
+
+// roundMoney rounds half away from zero to the currency's minor units
+func roundMoney(value *big.Rat, currency string) (money, error) {
+	scaled := new(big.Rat).Mul(value, minorUnitScale(currency))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(scaled.Num().Sign())))
+	}
+	if !quotient.IsInt64() {
+		return money{Currency: currency}, errors.New("Amount " + formatDecimal(value) + " " + currency + " is too large")
+	}
+	return money{Minor: quotient.Int64(), Currency: currency}, nil
+}
+
+// parseMoney accepts only amounts that fit the currency's minor units
+func parseMoney(value string, currency string) (money, error) {
+	decimal, err := parseDecimal(value)
+	if err != nil {
+		return money{}, err
+	}
+	amount, err := roundMoney(decimal, currency)
+	if err != nil {
+		return money{}, err
+	}
+	if amount.rat().Cmp(decimal) != 0 {
+		return money{}, errors.New(value + " has more than " + strconv.Itoa(minorUnits(currency)) + " decimals for " + currency)
+	}
+	return amount, nil
+}
+
+func (m money) rat() *big.Rat {
+	return new(big.Rat).Quo(new(big.Rat).SetInt64(m.Minor), minorUnitScale(m.Currency))
+}
+
+func (m money) String() string {
+	return m.rat().FloatString(minorUnits(m.Currency))
+}
+
+func (m money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m money) Add(other money) (money, error) {
+	if m.Currency != other.Currency {
+		return m, errors.New("Cannot add " + other.Currency + " to " + m.Currency)
+	}
+	sum := m.Minor + other.Minor
+	if (other.Minor > 0 && sum < m.Minor) || (other.Minor < 0 && sum > m.Minor) {
+		return m, errors.New("Amount " + m.String() + " plus " + other.String() + " " + m.Currency + " is too large")
+	}
+	return money{Minor: sum, Currency: m.Currency}, nil
+}
+
+func (m money) Sub(other money) (money, error) {
+	if m.Currency != other.Currency {
+		return m, errors.New("Cannot subtract " + other.Currency + " from " + m.Currency)
+	}
+	difference := m.Minor - other.Minor
+	if (other.Minor < 0 && difference < m.Minor) || (other.Minor > 0 && difference > m.Minor) {
+		return m, errors.New("Amount " + m.String() + " minus " + other.String() + " " + m.Currency + " is too large")
+	}
+	return money{Minor: difference, Currency: m.Currency}, nil
+}
+
+func (m money) Mul(factor *big.Rat) (money, error) {
+	return roundMoney(new(big.Rat).Mul(m.rat(), factor), m.Currency)
+}
+
+func (m money) Percentage(percentage float64) (money, error) {
+	factor, ok := new(big.Rat).SetString(strconv.FormatFloat(percentage, 'f', -1, 64))
+	if !ok {
+		return money{Currency: m.Currency}, errors.New("Invalid percentage " + strconv.FormatFloat(percentage, 'f', -1, 64))
+	}
+	return m.Mul(factor.Quo(factor, big.NewRat(100, 1)))
+}
+
+// inCurrency gives an amount decoded from a plain number, which has no currency, the currency it is held in
+func (m money) inCurrency(currency string) (money, error) {
+	if m.Currency != "" {
+		return m, nil
+	}
+	return roundMoney(m.rat(), currency)
+}
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+func (m *money) UnmarshalJSON(data []byte) error {
+	var value moneyJSON
+
+	//Amounts stored before money was introduced were plain numbers
+	if len(data) > 0 && data[0] != '{' {
+		decimal, err := parseDecimal(string(data))
+		if err != nil {
+			return err
+		}
+		*m, err = roundMoney(decimal, "")
+		return err
+	}
+
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	if value.Amount == "" {
+		*m = money{Currency: value.Currency}
+		return nil
+	}
+	amount, err := parseMoney(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
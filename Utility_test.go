@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		value    *big.Rat
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{big.NewRat(1005, 1000), "USD", 101, false},
+		{big.NewRat(-1005, 1000), "USD", -101, false},
+		{big.NewRat(1004, 1000), "USD", 100, false},
+		{big.NewRat(5, 10), "JPY", 1, false},
+		{big.NewRat(12345, 10000), "BHD", 1235, false},
+		{new(big.Rat).SetInt64(math.MaxInt64), "USD", 0, true},
+		{new(big.Rat).SetInt64(math.MinInt64), "USD", 0, true},
+	}
+
+	for _, test := range tests {
+		amount, err := roundMoney(test.value, test.currency)
+		if (err != nil) != test.wantErr {
+			t.Errorf("roundMoney(%s, %s) error = %v", test.value, test.currency, err)
+			continue
+		}
+		if !test.wantErr && amount.Minor != test.want {
+			t.Errorf("roundMoney(%s, %s) = %d, want %d", test.value, test.currency, amount.Minor, test.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     string
+		wantErr  bool
+	}{
+		{"100", "USD", "100.00", false},
+		{"1.5", "KWD", "1.500", false},
+		{"1000", "JPY", "1000", false},
+		{"1.005", "USD", "", true},
+		{"1.5", "JPY", "", true},
+		{"1e3", "USD", "", true},
+		{"abc", "USD", "", true},
+		{"99999999999999999999", "USD", "", true},
+	}
+
+	for _, test := range tests {
+		amount, err := parseMoney(test.value, test.currency)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseMoney(%s, %s) error = %v", test.value, test.currency, err)
+			continue
+		}
+		if !test.wantErr && amount.String() != test.want {
+			t.Errorf("parseMoney(%s, %s) = %s, want %s", test.value, test.currency, amount, test.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	large := money{Minor: math.MaxInt64 - 1, Currency: "USD"}
+	tests := []struct {
+		name    string
+		apply   func() (money, error)
+		want    int64
+		wantErr bool
+	}{
+		{"add", func() (money, error) { return money{100, "USD"}.Add(money{250, "USD"}) }, 350, false},
+		{"add currency mismatch", func() (money, error) { return money{100, "USD"}.Add(money{250, "EUR"}) }, 0, true},
+		{"add overflow", func() (money, error) { return large.Add(money{2, "USD"}) }, 0, true},
+		{"sub", func() (money, error) { return money{100, "USD"}.Sub(money{250, "USD"}) }, -150, false},
+		{"sub overflow", func() (money, error) { return money{math.MinInt64 + 1, "USD"}.Sub(money{2, "USD"}) }, 0, true},
+		{"percentage", func() (money, error) { return money{10000, "USD"}.Percentage(2.5) }, 250, false},
+		{"percentage rounds", func() (money, error) { return money{333, "USD"}.Percentage(50) }, 167, false},
+		{"percentage overflow", func() (money, error) { return large.Percentage(200) }, 0, true},
+		{"mul overflow", func() (money, error) { return large.Mul(big.NewRat(3, 2)) }, 0, true},
+	}
+
+	for _, test := range tests {
+		amount, err := test.apply()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error = %v", test.name, err)
+			continue
+		}
+		if !test.wantErr && amount.Minor != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, amount.Minor, test.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		data     string
+		want     int64
+		currency string
+		wantErr  bool
+	}{
+		{`{"amount":"1.234","currency":"BHD"}`, 1234, "BHD", false},
+		{`{"amount":"1000","currency":"JPY"}`, 1000, "JPY", false},
+		{`42.5`, 4250, "", false},
+		{`{"amount":"1.005","currency":"USD"}`, 0, "", true},
+		{`99999999999999999999`, 0, "", true},
+	}
+
+	for _, test := range tests {
+		var amount money
+		err := json.Unmarshal([]byte(test.data), &amount)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v", test.data, err)
+			continue
+		}
+		if !test.wantErr && (amount.Minor != test.want || amount.Currency != test.currency) {
+			t.Errorf("Unmarshal(%s) = %+v", test.data, amount)
+		}
+	}
+}
+
+func TestMoneyInCurrency(t *testing.T) {
+	tests := []struct {
+		amount   money
+		currency string
+		want     money
+	}{
+		{money{4200, ""}, "USD", money{4200, "USD"}},
+		{money{4200, ""}, "KWD", money{42000, "KWD"}},
+		{money{4200, ""}, "JPY", money{42, "JPY"}},
+		{money{4200, "EUR"}, "USD", money{4200, "EUR"}},
+	}
+
+	for _, test := range tests {
+		amount, err := test.amount.inCurrency(test.currency)
+		if err != nil || amount != test.want {
+			t.Errorf("%+v.inCurrency(%s) = %+v, %v, want %+v", test.amount, test.currency, amount, err, test.want)
+		}
+	}
+}